@@ -8,4 +8,4 @@ require (
 	github.com/gorilla/websocket v1.5.3
 )
 
-require github.com/joho/godotenv v1.5.1 // indirect
+require github.com/joho/godotenv v1.5.1
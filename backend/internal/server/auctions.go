@@ -0,0 +1,323 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Auction formats
+const (
+	auctionSealed  = "sealed"  // one round of hidden bids, winner pays their bid
+	auctionVickrey = "vickrey" // hidden bids, winner pays the second-highest bid
+	auctionEnglish = "english" // open ascending bids broadcast to the room
+	auctionDutch   = "dutch"   // price falls each turn until someone accepts
+)
+
+var auctionFormats = []string{auctionSealed, auctionVickrey, auctionEnglish, auctionDutch}
+
+const englishAuctionTurns = 3
+const englishMinIncrementPct = 5 // of the suggested bid
+const dutchAuctionSteps = 5
+const dutchStartPct = 200 // opening Dutch price as a percent of the suggested bid
+
+// auctionBid is one entry in the ranked bid list.
+type auctionBid struct {
+	Player PlayerID
+	Amount int
+	Seq    int
+}
+
+// spendableFunds is cash not tied up in open auction bids.
+func spendableFunds(p *Player) int {
+	return p.Money - p.ReservedFunds
+}
+
+func auctionFormatLabel(format string) string {
+	switch format {
+	case auctionVickrey:
+		return "sealed second-price"
+	case auctionEnglish:
+		return "open ascending"
+	case auctionDutch:
+		return "descending Dutch"
+	}
+	return "sealed first-price"
+}
+
+// auctionRules is the player-facing explanation shown when an auction opens.
+func auctionRules(a *FederationAuction) string {
+	switch a.Format {
+	case auctionVickrey:
+		return "Bids are sealed; the highest bidder wins but pays only the second-highest bid."
+	case auctionEnglish:
+		return fmt.Sprintf("Bids are public and must beat the current high bid by at least %d credits. A late bid extends the auction.", a.MinIncrement)
+	case auctionDutch:
+		return fmt.Sprintf("The price starts at %d credits and drops every turn. The first captain to accept wins at the current price.", a.CurrentPrice)
+	}
+	return "Bids are sealed; the highest bidder wins and pays their bid."
+}
+
+// setupAuctionFormat initialises per-format pricing on a freshly created auction.
+func setupAuctionFormat(a *FederationAuction, format string) {
+	a.Format = format
+	a.ReservePrice = maxInt(1, a.SuggestedBid/4)
+	a.Reserved = map[PlayerID]int{}
+	a.BidSeq = map[PlayerID]int{}
+	a.BotLimits = map[PlayerID]int{}
+	switch format {
+	case auctionEnglish:
+		a.TurnsLeft = englishAuctionTurns
+		a.MinIncrement = maxInt(1, a.SuggestedBid*englishMinIncrementPct/100)
+	case auctionDutch:
+		a.CurrentPrice = a.SuggestedBid * dutchStartPct / 100
+		a.ReservePrice = maxInt(1, a.SuggestedBid/2)
+		a.MinIncrement = maxInt(1, (a.CurrentPrice-a.ReservePrice)/dutchAuctionSteps)
+		a.TurnsLeft = dutchAuctionSteps + 1
+	}
+}
+
+// rankedBids orders bids by amount, then earliest bid, then player id.
+func rankedBids(a *FederationAuction) []auctionBid {
+	out := make([]auctionBid, 0, len(a.Bids))
+	for pid, amt := range a.Bids {
+		out = append(out, auctionBid{Player: pid, Amount: amt, Seq: a.BidSeq[pid]})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Amount != out[j].Amount {
+			return out[i].Amount > out[j].Amount
+		}
+		if out[i].Seq != out[j].Seq {
+			return out[i].Seq < out[j].Seq
+		}
+		return out[i].Player < out[j].Player
+	})
+	return out
+}
+
+// reserveBid ties up funds for an open bid, replacing any earlier reservation.
+func reserveBid(room *Room, a *FederationAuction, p *Player, amount int) {
+	releaseBid(room, a, p.ID)
+	a.Reserved[p.ID] = amount
+	p.ReservedFunds += amount
+}
+
+func releaseBid(room *Room, a *FederationAuction, pid PlayerID) {
+	amt, ok := a.Reserved[pid]
+	if !ok {
+		return
+	}
+	delete(a.Reserved, pid)
+	if pl := room.Players[pid]; pl != nil {
+		pl.ReservedFunds = maxInt(0, pl.ReservedFunds-amt)
+	}
+}
+
+// releasePlayerBids frees every reservation a departing player holds. Their bids
+// stand, but absent bidders are passed over when an auction settles.
+func releasePlayerBids(room *Room, p *Player) {
+	if room.ActiveAuction != nil {
+		releaseBid(room, room.ActiveAuction, p.ID)
+	}
+	for _, a := range room.Auctions {
+		releaseBid(room, a, p.ID)
+	}
+	p.ReservedFunds = 0
+}
+
+func releaseAllBids(room *Room, a *FederationAuction) {
+	for pid := range a.Reserved {
+		releaseBid(room, a, pid)
+	}
+}
+
+// recordBid validates and stores a bid according to the auction format. Returns
+// a player-facing rejection message, or "" when the bid was accepted.
+func (gs *GameServer) recordBid(room *Room, a *FederationAuction, p *Player, bid int) string {
+	if a.Format == auctionDutch {
+		// Any bid accepts the current price
+		bid = a.CurrentPrice
+	}
+	if bid < a.ReservePrice {
+		return fmt.Sprintf("The reserve price is %d credits.", a.ReservePrice)
+	}
+	if spendableFunds(p)+a.Reserved[p.ID] < bid {
+		return "You don't have enough unreserved credits for this bid."
+	}
+	if a.Format == auctionEnglish {
+		if a.HighBidder == p.ID {
+			return "You already hold the high bid."
+		}
+		if a.HighBidder != "" && bid < a.CurrentPrice+a.MinIncrement {
+			return fmt.Sprintf("Bids must be at least %d credits.", a.CurrentPrice+a.MinIncrement)
+		}
+		if prev := a.HighBidder; prev != "" {
+			releaseBid(room, a, prev)
+			if pl := room.Players[prev]; pl != nil && !pl.IsBot {
+				gs.enqueueModal(pl, "Outbid", fmt.Sprintf("%s bid %d credits for the %s.", p.Name, bid, lotLabel(a)))
+			}
+		}
+		a.CurrentPrice = bid
+		a.HighBidder = p.ID
+	}
+	a.nextSeq++
+	a.BidSeq[p.ID] = a.nextSeq
+	a.Bids[p.ID] = bid
+	reserveBid(room, a, p, bid)
+	if a.Format == auctionEnglish {
+		gs.logGeneral(room, fmt.Sprintf("%s bids $%d for %s", p.Name, bid, lotLabel(a)))
+	}
+	return ""
+}
+
+// placeBotBids sets each bot's private valuation and, for sealed formats, bids it.
+func (gs *GameServer) placeBotBids(room *Room, a *FederationAuction) {
+	for _, p := range room.Players {
+		if !p.IsBot || p.Bankrupt || p.ID == a.Seller || auctionBarred(room, p, a) {
+			continue
+		}
+		// Bots value the lot at 80-120% of suggested, but never more than they can afford
+		maxBid := spendableFunds(p) - 200 // Keep 200 credits as buffer
+		if maxBid <= 0 {
+			continue
+		}
+		limit := minInt(a.SuggestedBid+rand.Intn(maxInt(1, a.SuggestedBid/2))-a.SuggestedBid/4, maxBid)
+		if limit <= 0 {
+			continue
+		}
+		a.BotLimits[p.ID] = limit
+		if a.Format == auctionSealed || a.Format == auctionVickrey {
+			if gs.recordBid(room, a, p, limit) == "" {
+				gs.logAction(room, p, fmt.Sprintf("Auto-placed auction bid of $%d for %s", limit, lotLabel(a)))
+			}
+		}
+	}
+}
+
+// advanceAuction runs the per-turn step for open formats: bots respond to English
+// bids and Dutch prices fall (or are accepted). Returns true if the auction settled.
+func (gs *GameServer) advanceAuction(room *Room, a *FederationAuction) bool {
+	switch a.Format {
+	case auctionEnglish:
+		// On the closing turn bots only open the bidding, so they never outbid a
+		// human who has no turn left to answer
+		closing := a.TurnsLeft <= 1
+		for _, pid := range sortedBotIDs(a) {
+			if closing && a.HighBidder != "" {
+				break
+			}
+			p := room.Players[pid]
+			if p == nil || p.Bankrupt || a.HighBidder == pid {
+				continue
+			}
+			next := maxInt(a.ReservePrice, a.CurrentPrice+a.MinIncrement)
+			if a.HighBidder == "" {
+				next = a.ReservePrice
+			}
+			if next <= a.BotLimits[pid] && gs.recordBid(room, a, p, next) == "" {
+				gs.logAction(room, p, fmt.Sprintf("Auto-placed auction bid of $%d for %s", next, lotLabel(a)))
+			}
+		}
+	case auctionDutch:
+		a.CurrentPrice = maxInt(a.ReservePrice, a.CurrentPrice-a.MinIncrement)
+		for _, pid := range sortedBotIDs(a) {
+			p := room.Players[pid]
+			if p == nil || p.Bankrupt || a.CurrentPrice > a.BotLimits[pid] {
+				continue
+			}
+			if gs.recordBid(room, a, p, a.CurrentPrice) == "" {
+				gs.logAction(room, p, fmt.Sprintf("Accepted Dutch auction price of $%d for %s", a.CurrentPrice, lotLabel(a)))
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedBotIDs(a *FederationAuction) []PlayerID {
+	ids := make([]PlayerID, 0, len(a.BotLimits))
+	for pid := range a.BotLimits {
+		ids = append(ids, pid)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// pickAuctionWinner walks the ranked bids and returns the first bidder who can pay,
+// with the price owed under the auction's format. Bidders who can't cover their
+// price from cash not reserved for their other open bids forfeit and the next
+// bidder is tried.
+func (gs *GameServer) pickAuctionWinner(room *Room, a *FederationAuction) (*Player, int, []auctionBid) {
+	ranked := rankedBids(a)
+	for i, b := range ranked {
+		p := room.Players[b.Player]
+		if p == nil || p.Bankrupt {
+			continue
+		}
+		price := b.Amount
+		if a.Format == auctionVickrey {
+			price = a.ReservePrice
+			if i+1 < len(ranked) {
+				price = maxInt(price, ranked[i+1].Amount)
+			}
+		}
+		reason := ""
+		if spendableFunds(p) < price {
+			reason = fmt.Sprintf("You couldn't cover your %d-credit bid", price)
+		} else if a.LotKind == lotGoods && lotShip(a, p) == nil {
+			reason = "You had no ship docked at " + a.Planet + " with cargo space"
+		}
+		if reason != "" {
+			if !p.IsBot {
+				gs.enqueueModal(p, "Bid Forfeited", fmt.Sprintf("%s for the %s, so it passed to the next bidder.", reason, lotLabel(a)))
+			}
+			gs.logGeneral(room, fmt.Sprintf("%s forfeited their winning bid for %s", p.Name, lotLabel(a)))
+			continue
+		}
+		return p, price, ranked
+	}
+	return nil, 0, ranked
+}
+
+// auctionPayload publishes the live auction to a player.
+func auctionPayload(room *Room, a *FederationAuction, viewer *Player) map[string]interface{} {
+	if a == nil {
+		return nil
+	}
+	out := map[string]interface{}{
+		"id":           a.ID,
+		"format":       a.Format,
+		"facilityType": a.FacilityType,
+		"planet":       a.Planet,
+		"usageCharge":  a.UsageCharge,
+		"suggestedBid": a.SuggestedBid,
+		"reservePrice": a.ReservePrice,
+		"turnsLeft":    a.TurnsLeft,
+		"bidCount":     len(a.Bids),
+		"yourBid":      a.Bids[viewer.ID],
+	}
+	switch a.Format {
+	case auctionEnglish:
+		out["currentPrice"] = a.CurrentPrice
+		out["minIncrement"] = a.MinIncrement
+		if hb := room.Players[a.HighBidder]; hb != nil {
+			out["highBidder"] = hb.Name
+		}
+	case auctionDutch:
+		out["currentPrice"] = a.CurrentPrice
+	}
+	if a.Seller != "" {
+		out["seller"] = a.Seller
+		out["sellerName"] = a.SellerName
+		out["lotKind"] = a.LotKind
+		out["lot"] = lotLabel(a)
+		out["good"] = a.Good
+		out["quantity"] = a.Quantity
+		out["upgradeKind"] = a.UpgradeKind
+		out["facilityId"] = a.FacilityID
+	}
+	if intel := guildAuctionIntel(room, a, viewer); intel != nil {
+		out["guildIntel"] = intel
+	}
+	return out
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+const bankPlanetCount = 3
+const minCreditLine = 1000     // credit every captain gets regardless of assets
+const loanRateBP = 100         // interest per turn in basis points (1%)
+const loanPaymentInterval = 5  // turns between required payments
+const loanMinPaymentPct = 10   // percent of the balance due each payment period
+const loanMinPayment = 50      // floor for the periodic payment
+const missedPaymentFee = 100   // flat penalty added per missed payment
+const repossessAfterMissed = 2 // missed payments before the bank seizes assets
+const repossessionRecoveryPct = 50
+
+// Loan is a player's outstanding balance with the Federation bank. Unpaid fees and
+// taxes also land here as an overdraft when a player runs out of cash.
+type Loan struct {
+	Balance        int
+	RateBP         int
+	NextDueTurn    int
+	PaidThisPeriod int
+	Missed         int
+}
+
+func cloneLoan(l *Loan) *Loan {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	return &c
+}
+
+// minimumPayment is what the bank expects by NextDueTurn.
+func (l *Loan) minimumPayment() int {
+	return maxInt(loanMinPayment, l.Balance*loanMinPaymentPct/100)
+}
+
+func (l *Loan) amountDue() int {
+	return maxInt(0, minInt(l.Balance, l.minimumPayment())-l.PaidThisPeriod)
+}
+
+// pickBankPlanets chooses the planets hosting a Federation bank branch. Earth always has one.
+func pickBankPlanets(names []string) []string {
+	out := []string{}
+	others := []string{}
+	for _, n := range names {
+		if n == "Earth" {
+			out = append(out, n)
+		} else {
+			others = append(others, n)
+		}
+	}
+	rand.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+	for _, n := range others {
+		if len(out) >= bankPlanetCount {
+			break
+		}
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func roomHasBank(room *Room, planet string) bool {
+	for _, n := range room.BankPlanets {
+		if n == planet {
+			return true
+		}
+	}
+	return false
+}
+
+func loanBalance(p *Player) int {
+	if p.Loan == nil {
+		return 0
+	}
+	return p.Loan.Balance
+}
+
+// playerAssets is everything the bank could seize: cash, cargo at cost, upgrades, facilities and ships.
+func playerAssets(p *Player) int {
+	assets := maxInt(0, p.Money)
+	for _, s := range p.ships() {
+		assets += inventoryValue(s.Inventory, s.InventoryAvgCost)
+	}
+	return assets + p.UpgradeInvestment + p.FacilityInvestment + p.FleetInvestment
+}
+
+// netWorth is assets minus Federation debt.
+func netWorth(p *Player) int {
+	return playerAssets(p) - loanBalance(p)
+}
+
+// creditLimit is how much the bank will lend against a player's net worth, so
+// borrowed cash doesn't raise the limit.
+func creditLimit(p *Player) int {
+	return maxInt(minCreditLine, netWorth(p)/2)
+}
+
+func (gs *GameServer) ensureLoan(room *Room, p *Player) *Loan {
+	if p.Loan == nil {
+		p.Loan = &Loan{RateBP: loanRateBP, NextDueTurn: room.Turn + loanPaymentInterval}
+	}
+	return p.Loan
+}
+
+// handleTakeLoan borrows from the bank branch at the flagship's planet.
+func (gs *GameServer) handleTakeLoan(room *Room, p *Player, amount int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if amount <= 0 {
+		return
+	}
+	if p.InTransit || !roomHasBank(room, p.CurrentPlanet) {
+		gs.enqueueModal(p, "No Bank Branch", "Loans are only issued in person at a Federation bank branch.")
+		return
+	}
+	available := creditLimit(p) - loanBalance(p)
+	if amount > available {
+		gs.enqueueModal(p, "Credit Limit Reached", fmt.Sprintf("The bank will only extend another %d credits against your assets.", maxInt(0, available)))
+		return
+	}
+	loan := gs.ensureLoan(room, p)
+	loan.Balance += amount
+	p.Money += amount
+	gs.logAction(room, p, fmt.Sprintf("Took a $%d loan at %s (balance $%d)", amount, p.CurrentPlanet, loan.Balance))
+	gs.enqueueModal(p, "Loan Approved", fmt.Sprintf("The Federation bank advances you %d credits at %d.%02d%% per turn. Minimum payments of %d credits are due every %d turns.", amount, loan.RateBP/100, loan.RateBP%100, loan.minimumPayment(), loanPaymentInterval))
+}
+
+// handleRepayLoan pays down the balance; amount<=0 repays as much as cash allows.
+func (gs *GameServer) handleRepayLoan(room *Room, p *Player, amount int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if p.Loan == nil {
+		return
+	}
+	if amount <= 0 || amount > p.Loan.Balance {
+		amount = p.Loan.Balance
+	}
+	amount = minInt(amount, spendableFunds(p))
+	if amount <= 0 {
+		gs.enqueueModal(p, "Insufficient Funds", "You don't have any credits to put toward your loan.")
+		return
+	}
+	gs.applyLoanPayment(room, p, amount)
+}
+
+func (gs *GameServer) applyLoanPayment(room *Room, p *Player, amount int) {
+	loan := p.Loan
+	p.Money -= amount
+	loan.Balance -= amount
+	loan.PaidThisPeriod += amount
+	if loan.Balance <= 0 {
+		p.Loan = nil
+		gs.logAction(room, p, fmt.Sprintf("Repaid $%d and cleared Federation loan", amount))
+		return
+	}
+	gs.logAction(room, p, fmt.Sprintf("Repaid $%d of Federation loan (balance $%d)", amount, loan.Balance))
+}
+
+// chargeWithCredit deducts a mandatory charge (dock tax, fees, taxes). Any shortfall
+// becomes an overdraft on the player's loan; if the debt then exceeds their credit
+// limit the bank repossesses assets and, failing that, impounds the fleet.
+func (gs *GameServer) chargeWithCredit(room *Room, p *Player, amount int, cause, planet string) {
+	p.Money -= amount
+	gs.rollOverdraft(room, p)
+	gs.enforceCreditLimit(room, p, cause, planet)
+}
+
+// rollOverdraft moves negative cash onto the loan balance.
+func (gs *GameServer) rollOverdraft(room *Room, p *Player) {
+	if p.Money >= 0 {
+		return
+	}
+	short := -p.Money
+	loan := gs.ensureLoan(room, p)
+	loan.Balance += short
+	p.Money = 0
+	gs.logAction(room, p, fmt.Sprintf("Overdraft: $%d added to Federation loan", short))
+}
+
+func (gs *GameServer) enforceCreditLimit(room *Room, p *Player, cause, planet string) {
+	if p.Loan == nil || p.Bankrupt {
+		return
+	}
+	if excess := p.Loan.Balance - creditLimit(p); excess > 0 {
+		// Cash on hand goes to the bank before anything is seized
+		if pay := minInt(excess, spendableFunds(p)); pay > 0 {
+			gs.applyLoanPayment(room, p, pay)
+			excess -= pay
+		}
+		gs.repossess(room, p, excess)
+	}
+	if p.Loan != nil && p.Loan.Balance > creditLimit(p) {
+		gs.bankruptPlayer(room, p, cause, planet)
+	}
+}
+
+// bankruptPlayer impounds the player's fleet and announces it.
+func (gs *GameServer) bankruptPlayer(room *Room, p *Player, cause, planet string) {
+	if p.Bankrupt {
+		return
+	}
+	// Queue Game Over first, then mark bankrupt to preserve the modal
+	if !p.IsBot {
+		gs.enqueueModal(p, "Game Over", "Your ship was impounded for unpaid "+cause+". You may continue watching.")
+	}
+	p.Bankrupt = true
+	room.News = append(room.News, NewsItem{Headline: p.Name + " bankrupted by " + cause + " at " + planet, Planet: planet, TurnsRemaining: 3})
+}
+
+// repossess seizes cargo, then upgrades, then fleet hulls until target credits have been recovered.
+// Proceeds go straight to the loan balance. Returns the amount recovered.
+func (gs *GameServer) repossess(room *Room, p *Player, target int) int {
+	if p.Loan == nil || target <= 0 {
+		return 0
+	}
+	recovered := 0
+	seized := []string{}
+	for _, ship := range p.ships() {
+		goods := make([]string, 0, len(ship.Inventory))
+		for g := range ship.Inventory {
+			goods = append(goods, g)
+		}
+		sort.Strings(goods)
+		for _, g := range goods {
+			if recovered >= target {
+				break
+			}
+			qty := ship.Inventory[g]
+			if qty <= 0 {
+				continue
+			}
+			price := ship.InventoryAvgCost[g]
+			if pl := room.Planets[ship.CurrentPlanet]; pl != nil && !ship.InTransit {
+				price = pl.Prices[g]
+				pl.Goods[g] += qty
+			}
+			recovered += qty * price
+			seized = append(seized, fmt.Sprintf("%d %s", qty, g))
+			delete(ship.Inventory, g)
+			delete(ship.InventoryAvgCost, g)
+		}
+	}
+	if recovered < target && p.UpgradeInvestment > 0 {
+		value := p.UpgradeInvestment * repossessionRecoveryPct / 100
+		recovered += value
+		seized = append(seized, "ship upgrades")
+		p.CapacityBonus = 0
+		p.SpeedBonus = 0
+		p.FuelCapacityBonus = 0
+		p.Weapons = 0
+		p.Shields = 0
+		p.Fuel = minInt(p.Fuel, p.maxFuel())
+		p.UpgradeInvestment = 0
+	}
+	// Hulls go last, most recently commissioned first
+	for len(p.Fleet) > 0 && recovered < target {
+		ship := p.Fleet[len(p.Fleet)-1]
+		p.Fleet = p.Fleet[:len(p.Fleet)-1]
+		hull := minInt(shipPrice, p.FleetInvestment)
+		p.FleetInvestment -= hull
+		recovered += hull * repossessionRecoveryPct / 100
+		seized = append(seized, "the "+ship.Name)
+	}
+	if recovered == 0 {
+		return 0
+	}
+	p.Loan.Balance -= recovered
+	if p.Loan.Balance <= 0 {
+		p.Money += -p.Loan.Balance
+		p.Loan = nil
+	}
+	gs.logAction(room, p, fmt.Sprintf("Bank repossessed assets worth $%d", recovered))
+	gs.adjustAllReputation(room, p, -reputationRepossessionLoss, "assets repossessed")
+	if !p.IsBot {
+		gs.enqueueModal(p, "Repossession", fmt.Sprintf("Federation bailiffs seized %s to recover %d credits of debt.", joinList(seized), recovered))
+	}
+	return recovered
+}
+
+// processLoans accrues interest, sweeps negative balances into overdraft and
+// collects periodic payments. Called once per turn from the ticker.
+func (gs *GameServer) processLoans(room *Room) {
+	for _, p := range room.Players {
+		if p.Bankrupt {
+			continue
+		}
+		gs.rollOverdraft(room, p)
+		loan := p.Loan
+		if loan == nil {
+			continue
+		}
+		interest := (loan.Balance*loan.RateBP + 9999) / 10000
+		loan.Balance += interest
+		if room.Turn >= loan.NextDueTurn {
+			due := loan.amountDue()
+			if due > 0 && spendableFunds(p) >= due {
+				gs.applyLoanPayment(room, p, due)
+				gs.logAction(room, p, fmt.Sprintf("Automatic loan payment: $%d", due))
+			} else if due > 0 {
+				// Pay what we can, then penalise the rest
+				if cash := spendableFunds(p); cash > 0 {
+					gs.applyLoanPayment(room, p, cash)
+				}
+				if p.Loan != nil {
+					loan.Missed++
+					penalty := missedPaymentFee + loan.Balance*5/100
+					loan.Balance += penalty
+					gs.logAction(room, p, fmt.Sprintf("Missed loan payment: $%d penalty", penalty))
+					gs.adjustAllReputation(room, p, -reputationMissedPaymentLoss, "missed a loan payment")
+					if !p.IsBot {
+						gs.enqueueModal(p, "Missed Loan Payment", fmt.Sprintf("You missed a payment of %d credits. A %d-credit penalty has been added to your balance. Missed payments: %d.", due, penalty, loan.Missed))
+					}
+					if loan.Missed >= repossessAfterMissed {
+						gs.repossess(room, p, due+penalty)
+					}
+				}
+			}
+			if p.Loan != nil {
+				p.Loan.NextDueTurn = room.Turn + loanPaymentInterval
+				p.Loan.PaidThisPeriod = 0
+			}
+		}
+		gs.enforceCreditLimit(room, p, "debts", p.CurrentPlanet)
+	}
+}
+
+// loanPayload describes a player's loan and credit for the `you` payload.
+func loanPayload(p *Player) map[string]interface{} {
+	out := map[string]interface{}{
+		"creditLimit": creditLimit(p),
+		"netWorth":    netWorth(p),
+	}
+	if p.Loan != nil {
+		out["balance"] = p.Loan.Balance
+		out["rateBp"] = p.Loan.RateBP
+		out["nextDueTurn"] = p.Loan.NextDueTurn
+		out["amountDue"] = p.Loan.amountDue()
+		out["missed"] = p.Loan.Missed
+	}
+	return out
+}
+
+func joinList(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	out := ""
+	for i, it := range items {
+		switch {
+		case i == 0:
+			out = it
+		case i == len(items)-1:
+			out += " and " + it
+		default:
+			out += ", " + it
+		}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package server
+
+import "fmt"
+
+// ModalChoice is one option on a multi-choice modal.
+type ModalChoice struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	Cost        int    `json:"cost,omitempty"`   // charged when chosen
+	Chance      int    `json:"chance,omitempty"` // success percent, 0 when there is no roll
+}
+
+func (m ModalItem) choice(id string) *ModalChoice {
+	for i := range m.Choices {
+		if m.Choices[i].ID == id {
+			return &m.Choices[i]
+		}
+	}
+	return nil
+}
+
+// choiceAvailable re-checks a choice against the current game state, giving the
+// reason when it can no longer be taken.
+func choiceAvailable(room *Room, p *Player, m ModalItem, c ModalChoice) (bool, string) {
+	if p.Bankrupt {
+		return false, "Bankrupt captains can't act on offers."
+	}
+	if c.Cost > 0 && spendableFunds(p) < c.Cost {
+		return false, fmt.Sprintf("You need %d credits for that.", c.Cost)
+	}
+	switch m.Kind {
+	case "pirate-encounter":
+		if findEncounter(room, m.EncounterID) == nil {
+			return false, "The pirates are gone."
+		}
+	case "event-choice":
+		card := findEventCard(m.EventID)
+		if card == nil || room.DisabledEvents[card.ID] {
+			return false, "This event is no longer running."
+		}
+		ec := card.choice(c.ID)
+		if ec == nil {
+			return false, "That option is no longer on offer."
+		}
+		if !conditionsHold(room, p, ec.Conditions) {
+			return false, defaultStr(ec.Unavailable, "Your situation has changed and that option is no longer possible.")
+		}
+	}
+	return true, ""
+}
+
+// prependModal shows a notice ahead of the rest of the queue, so a refused choice
+// is explained before the choice is offered again.
+func prependModal(p *Player, title, body string) {
+	p.Modals = append([]ModalItem{{ID: randID(), Title: title, Body: body}}, p.Modals...)
+}
+
+// resolveModalChoice answers the multi-choice modal at the front of the player's
+// queue. Unknown, unaffordable or no longer possible choices leave the modal queued;
+// lapsed ones are dropped. Callers hold the room lock.
+func (gs *GameServer) resolveModalChoice(room *Room, p *Player, choiceID string, accept bool) {
+	m := p.Modals[0]
+	if choiceID == "" && len(m.Choices) > 0 {
+		// Accept/decline clients: pirates take tribute or see you flee; other
+		// modals take the first choice on accept and the last on decline
+		switch {
+		case m.Kind == "pirate-encounter" && accept:
+			choiceID = encounterTribute
+		case m.Kind == "pirate-encounter":
+			choiceID = encounterFlee
+		case accept:
+			choiceID = m.Choices[0].ID
+		default:
+			choiceID = m.Choices[len(m.Choices)-1].ID
+		}
+	}
+	if m.ExpiresTurn > 0 && room.Turn > m.ExpiresTurn {
+		p.Modals = append([]ModalItem(nil), p.Modals[1:]...)
+		gs.enqueueModal(p, "Too Late", "That opportunity has passed.")
+		return
+	}
+	c := m.choice(choiceID)
+	if c == nil {
+		prependModal(p, "Choice Unavailable", "That option isn't on offer.")
+		return
+	}
+	if ok, why := choiceAvailable(room, p, m, *c); !ok {
+		prependModal(p, "Choice Unavailable", why)
+		return
+	}
+	p.Modals = append([]ModalItem(nil), p.Modals[1:]...)
+	switch m.Kind {
+	case "pirate-encounter":
+		gs.resolveEncounter(room, findEncounter(room, m.EncounterID), c.ID)
+	case "event-choice":
+		gs.resolveEventChoice(room, p, m, *c)
+	}
+}
+
+// choicesPayload lists a modal's choices with whether each can be taken right now.
+func choicesPayload(room *Room, p *Player, m ModalItem) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(m.Choices))
+	for _, c := range m.Choices {
+		ok, why := choiceAvailable(room, p, m, c)
+		entry := map[string]interface{}{
+			"id":        c.ID,
+			"label":     c.Label,
+			"available": ok,
+		}
+		if c.Description != "" {
+			entry["description"] = c.Description
+		}
+		if c.Cost != 0 {
+			entry["cost"] = c.Cost
+		}
+		if c.Chance != 0 {
+			entry["chance"] = c.Chance
+		}
+		if !ok {
+			entry["reason"] = why
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const escortCostPerTurn = 150 // charged for each turn an escorted ship spends in transit
+const escortWeapons = 2       // armament an escort adds in a fight
+const escortShields = 2
+const maxConvoySize = 4
+
+// Convoy is a group of flagships travelling the same leg together. Members move
+// at the slowest member's pace and face pirates as one.
+type Convoy struct {
+	ID          string
+	From        string
+	Destination string
+	Members     []PlayerID
+	moveCap     int // this turn's shared movement, set before travel
+}
+
+func findConvoy(room *Room, id string) *Convoy {
+	for _, c := range room.Convoys {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func playerConvoy(room *Room, p *Player) *Convoy {
+	if p.ConvoyID == "" {
+		return nil
+	}
+	return findConvoy(room, p.ConvoyID)
+}
+
+// handleSetEscort hires or dismisses an NPC escort for a ship.
+func (gs *GameServer) handleSetEscort(room *Room, p *Player, shipID string, enabled bool) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	ship := p.shipByID(shipID)
+	if ship == nil || ship.Escort == enabled {
+		return
+	}
+	ship.Escort = enabled
+	if enabled {
+		gs.logAction(room, p, fmt.Sprintf("Hired an escort at $%d per turn of travel%s", escortCostPerTurn, shipSuffix(p, ship)))
+	} else {
+		gs.logAction(room, p, "Dismissed escort"+shipSuffix(p, ship))
+	}
+}
+
+// chargeEscorts bills every escorted ship in transit. Runs after travel.
+func (gs *GameServer) chargeEscorts(room *Room) {
+	for _, p := range room.Players {
+		if p.Bankrupt {
+			continue
+		}
+		for _, ship := range p.ships() {
+			if ship.Escort && ship.InTransit {
+				gs.logAction(room, p, fmt.Sprintf("Escort fee paid: $%d%s", escortCostPerTurn, shipSuffix(p, ship)))
+				gs.chargeWithCredit(room, p, escortCostPerTurn, "escort fees", ship.TransitFrom)
+			}
+		}
+	}
+}
+
+// handleInviteConvoy asks another captain docked at the same planet and bound for
+// the same destination to travel together.
+func (gs *GameServer) handleInviteConvoy(room *Room, p *Player, targetID PlayerID) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	target := room.Players[targetID]
+	if target == nil || target.ID == p.ID || target.Bankrupt {
+		return
+	}
+	if reason := convoyMismatch(p, target); reason != "" {
+		gs.enqueueModal(p, "Convoy Unavailable", reason)
+		return
+	}
+	if c := playerConvoy(room, p); c != nil && len(c.Members) >= maxConvoySize {
+		gs.enqueueModal(p, "Convoy Full", fmt.Sprintf("Convoys are limited to %d ships.", maxConvoySize))
+		return
+	}
+	if target.ConvoyID != "" && target.ConvoyID == p.ConvoyID {
+		return
+	}
+	gs.logAction(room, p, "Invited "+target.Name+" to travel in convoy to "+p.DestinationPlanet)
+	if target.IsBot {
+		gs.joinConvoy(room, p, target)
+		return
+	}
+	gs.enqueueModal(target, "Convoy Invitation", fmt.Sprintf("%s invites you to travel in convoy from %s to %s. Convoys move at the slowest ship's speed but face pirates together. Accept?", p.Name, p.CurrentPlanet, p.DestinationPlanet))
+	m := &target.Modals[len(target.Modals)-1]
+	m.Kind = "convoy-invite"
+	m.InviterID = p.ID
+	m.Destination = p.DestinationPlanet
+}
+
+// convoyMismatch explains why two flagships can't form a convoy, or returns "".
+func convoyMismatch(a, b *Player) string {
+	switch {
+	case a.InTransit || b.InTransit:
+		return "Convoys form in port; both ships must be docked."
+	case a.DestinationPlanet == "" || a.DestinationPlanet == a.CurrentPlanet:
+		return "Set a destination before forming a convoy."
+	case a.CurrentPlanet != b.CurrentPlanet || a.DestinationPlanet != b.DestinationPlanet:
+		return b.Name + " isn't docked here with the same destination."
+	}
+	return ""
+}
+
+// resolveConvoyInvite handles a response to a convoy-invite modal.
+func (gs *GameServer) resolveConvoyInvite(room *Room, p *Player, m ModalItem, accept bool) {
+	inviter := room.Players[m.InviterID]
+	if !accept {
+		if inviter != nil {
+			gs.logAction(room, inviter, p.Name+" declined your convoy invitation")
+		}
+		return
+	}
+	if inviter == nil || inviter.Bankrupt || inviter.DestinationPlanet != m.Destination {
+		gs.enqueueModal(p, "Convoy Unavailable", "The convoy has already changed plans.")
+		return
+	}
+	if reason := convoyMismatch(inviter, p); reason != "" {
+		gs.enqueueModal(p, "Convoy Unavailable", "Set course for "+m.Destination+" from "+inviter.CurrentPlanet+" to join this convoy.")
+		return
+	}
+	gs.joinConvoy(room, inviter, p)
+}
+
+func (gs *GameServer) joinConvoy(room *Room, inviter, p *Player) {
+	c := playerConvoy(room, inviter)
+	if c == nil {
+		c = &Convoy{ID: "convoy_" + randID(), From: inviter.CurrentPlanet, Destination: inviter.DestinationPlanet, Members: []PlayerID{inviter.ID}}
+		room.Convoys = append(room.Convoys, c)
+		inviter.ConvoyID = c.ID
+	}
+	if len(c.Members) >= maxConvoySize {
+		if !p.IsBot {
+			gs.enqueueModal(p, "Convoy Full", fmt.Sprintf("Convoys are limited to %d ships.", maxConvoySize))
+		}
+		return
+	}
+	gs.leaveConvoy(room, p, "")
+	c.Members = append(c.Members, p.ID)
+	p.ConvoyID = c.ID
+	for _, id := range c.Members {
+		if m := room.Players[id]; m != nil {
+			gs.logAction(room, m, fmt.Sprintf("%s joined the convoy to %s", p.Name, c.Destination))
+		}
+	}
+}
+
+// leaveConvoy removes a player from their convoy, dissolving it if one ship remains.
+// reason is announced to the remaining members; empty means a quiet exit.
+func (gs *GameServer) leaveConvoy(room *Room, p *Player, reason string) {
+	c := playerConvoy(room, p)
+	p.ConvoyID = ""
+	if c == nil {
+		return
+	}
+	gs.dropConvoyMember(room, c, p.ID)
+	if reason == "" {
+		return
+	}
+	for _, id := range c.Members {
+		if m := room.Players[id]; m != nil {
+			gs.logAction(room, m, fmt.Sprintf("%s %s and split from the convoy", p.Name, reason))
+			if !m.IsBot {
+				gs.enqueueModal(m, "Convoy Split", fmt.Sprintf("%s %s and left the convoy to %s.", p.Name, reason, c.Destination))
+			}
+		}
+	}
+}
+
+func (gs *GameServer) dropConvoyMember(room *Room, c *Convoy, pid PlayerID) {
+	for i, id := range c.Members {
+		if id == pid {
+			c.Members = append(c.Members[:i:i], c.Members[i+1:]...)
+			break
+		}
+	}
+	if len(c.Members) >= 2 {
+		return
+	}
+	for _, id := range c.Members {
+		if m := room.Players[id]; m != nil {
+			m.ConvoyID = ""
+		}
+	}
+	for i, other := range room.Convoys {
+		if other == c {
+			room.Convoys = append(room.Convoys[:i:i], room.Convoys[i+1:]...)
+			break
+		}
+	}
+}
+
+// maintainConvoys splits off members who left, went bankrupt, arrived or changed
+// course, then sets the shared movement for the turn. Runs before travel.
+func (gs *GameServer) maintainConvoys(room *Room) {
+	for _, c := range append([]*Convoy(nil), room.Convoys...) {
+		for _, id := range append([]PlayerID(nil), c.Members...) {
+			m := room.Players[id]
+			switch {
+			case m == nil || m.ConvoyID != c.ID:
+				gs.dropConvoyMember(room, c, id)
+			case m.Bankrupt:
+				gs.leaveConvoy(room, m, "was impounded")
+			case !m.InTransit && m.CurrentPlanet == c.Destination:
+				gs.leaveConvoy(room, m, "")
+			case m.DestinationPlanet != c.Destination:
+				gs.leaveConvoy(room, m, "changed course")
+			case m.InTransit && m.TransitRemaining != convoyRemaining(room, c):
+				gs.leaveConvoy(room, m, "fell behind")
+			}
+		}
+	}
+	for _, c := range room.Convoys {
+		c.moveCap = -1
+		for _, id := range c.Members {
+			if m := room.Players[id]; m != nil && (c.moveCap < 0 || m.speed() < c.moveCap) {
+				c.moveCap = m.speed()
+			}
+		}
+	}
+}
+
+// convoyRemaining is the distance left for the convoy's lead ship, or 0 before departure.
+func convoyRemaining(room *Room, c *Convoy) int {
+	if m := room.Players[c.Members[0]]; m != nil && m.InTransit {
+		return m.TransitRemaining
+	}
+	return 0
+}
+
+// convoyMoveCap limits a flagship to its convoy's pace; other ships move freely.
+func convoyMoveCap(room *Room, p *Player, ship *Ship, moveCap int) int {
+	if !p.isFlagship(ship) {
+		return moveCap
+	}
+	if c := playerConvoy(room, p); c != nil && c.moveCap >= 0 {
+		return minInt(moveCap, c.moveCap)
+	}
+	return moveCap
+}
+
+// convoyStrength is the weapons and shields a ship brings to a fight: its own,
+// its escort's, and every other ship in its convoy.
+func convoyStrength(room *Room, p *Player, ship *Ship) (weapons, shields int) {
+	weapons, shields = ship.Weapons, ship.Shields
+	if ship.Escort {
+		weapons += escortWeapons
+		shields += escortShields
+	}
+	if !p.isFlagship(ship) {
+		return
+	}
+	if c := playerConvoy(room, p); c != nil {
+		for _, id := range c.Members {
+			if m := room.Players[id]; m != nil && m.ID != p.ID {
+				weapons += m.Weapons
+				shields += m.Shields
+				if m.Escort {
+					weapons += escortWeapons
+					shields += escortShields
+				}
+			}
+		}
+	}
+	return
+}
+
+// encounterTarget picks who pirates single out: a random convoy member stands in
+// for the whole convoy, which is only rolled once per turn.
+func encounterTarget(room *Room, p *Player, ship *Ship, rolled map[string]bool) (*Player, *Ship, bool) {
+	c := playerConvoy(room, p)
+	if c == nil || !p.isFlagship(ship) {
+		return p, ship, true
+	}
+	if rolled[c.ID] {
+		return nil, nil, false
+	}
+	rolled[c.ID] = true
+	m := room.Players[c.Members[rand.Intn(len(c.Members))]]
+	if m == nil {
+		return p, ship, true
+	}
+	return m, &m.Ship, true
+}
+
+// convoysPayload lists active convoys for room state.
+func convoysPayload(room *Room) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(room.Convoys))
+	for _, c := range room.Convoys {
+		names := make([]string, 0, len(c.Members))
+		for _, id := range c.Members {
+			if m := room.Players[id]; m != nil {
+				names = append(names, m.Name)
+			}
+		}
+		out = append(out, map[string]interface{}{
+			"id":          c.ID,
+			"from":        c.From,
+			"destination": c.Destination,
+			"members":     c.Members,
+			"memberNames": strings.Join(names, ", "),
+		})
+	}
+	return out
+}
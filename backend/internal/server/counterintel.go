@@ -0,0 +1,225 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const investigationMinCost = 250
+const investigationMaxCost = 5000 // spending this buys the best odds
+const investigationMinChancePct = 5
+const investigationMaxChancePct = 90
+const exposureMinFine = 1000
+const exposureRestitutionPct = 50 // of the fine, paid to whoever exposed the instigator
+const reputationExposedLoss = 20
+const protectionCostPerTurn = 200
+const protectionMaxTurns = 20
+const protectionCatchPct = 25 // chance a foiled attack also exposes the instigator
+const maxIncidentHistory = 10 // per target, oldest dropped first
+
+// BlackOpsIncident records a setback a black ops contract caused, so the target
+// can investigate it later. The instigator is only revealed once Exposed.
+type BlackOpsIncident struct {
+	ID             string
+	ContractID     string
+	Target         PlayerID
+	Instigator     PlayerID
+	InstigatorName string
+	Price          int
+	Turn           int
+	Desc           string
+	Investigated   bool
+	Exposed        bool
+}
+
+// investigationChance is the percent chance that spending amount identifies the instigator.
+func investigationChance(amount int) int {
+	return clampInt(amount*investigationMaxChancePct/investigationMaxCost, investigationMinChancePct, investigationMaxChancePct)
+}
+
+// foilBlackOpsHit stops a contract against a protected target. Returns true if
+// the hit was foiled; the security detail may also unmask the instigator.
+func (gs *GameServer) foilBlackOpsHit(room *Room, contract *BlackOpsContract, target *Player) bool {
+	if target.ProtectedUntil < room.Turn {
+		return false
+	}
+	contract.Applied[target.ID] = true
+	gs.logAction(room, target, "Security detail foiled a covert attack")
+	if !target.IsBot {
+		gs.enqueueModal(target, "Attack Foiled", "Your security detail caught saboteurs tampering with your ship and drove them off.")
+	}
+	if inst := room.Players[contract.Instigator]; inst != nil {
+		gs.logAction(room, inst, fmt.Sprintf("Black ops against %s foiled by security", target.Name))
+	}
+	if rand.Intn(100) < protectionCatchPct {
+		inc := gs.recordIncident(room, contract, target, "attempted sabotage foiled by security")
+		inc.Investigated = true
+		gs.exposeInstigator(room, inc, target)
+	}
+	return true
+}
+
+// recordIncident files a setback so the target can investigate it.
+func (gs *GameServer) recordIncident(room *Room, contract *BlackOpsContract, target *Player, desc string) *BlackOpsIncident {
+	inc := &BlackOpsIncident{
+		ID:         "incident_" + randID(),
+		ContractID: contract.ID,
+		Target:     target.ID,
+		Instigator: contract.Instigator,
+		Price:      contract.Price,
+		Turn:       room.Turn,
+		Desc:       desc,
+	}
+	if inst := room.Players[contract.Instigator]; inst != nil {
+		inc.InstigatorName = inst.Name
+	}
+	room.Incidents = append(room.Incidents, inc)
+	// Keep only the most recent incidents per target
+	count := 0
+	kept := make([]*BlackOpsIncident, 0, len(room.Incidents))
+	for i := len(room.Incidents) - 1; i >= 0; i-- {
+		c := room.Incidents[i]
+		if c.Target == target.ID {
+			count++
+			if count > maxIncidentHistory {
+				continue
+			}
+		}
+		kept = append(kept, c)
+	}
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	room.Incidents = kept
+	return inc
+}
+
+// botInvestigate lets a well-funded bot look into a fresh setback.
+func (gs *GameServer) botInvestigate(room *Room, bot *Player, inc *BlackOpsIncident) {
+	if !bot.IsBot || spendableFunds(bot) < 4000 || rand.Intn(2) == 0 {
+		return
+	}
+	gs.investigateIncident(room, bot, inc, 1000)
+}
+
+func findIncident(room *Room, id string) *BlackOpsIncident {
+	for _, inc := range room.Incidents {
+		if inc.ID == id {
+			return inc
+		}
+	}
+	return nil
+}
+
+// handleInvestigate pays investigators to look into a setback.
+func (gs *GameServer) handleInvestigate(room *Room, p *Player, incidentID string, amount int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	inc := findIncident(room, incidentID)
+	if inc == nil || inc.Target != p.ID {
+		return
+	}
+	if inc.Investigated {
+		gs.enqueueModal(p, "Case Closed", "Investigators have already looked into that incident.")
+		return
+	}
+	amount = clampInt(amount, investigationMinCost, investigationMaxCost)
+	if spendableFunds(p) < amount {
+		gs.enqueueModal(p, "Insufficient Funds", fmt.Sprintf("An investigation of that scope costs %d credits.", amount))
+		return
+	}
+	gs.investigateIncident(room, p, inc, amount)
+}
+
+func (gs *GameServer) investigateIncident(room *Room, p *Player, inc *BlackOpsIncident, amount int) {
+	p.Money -= amount
+	inc.Investigated = true
+	chance := investigationChance(amount)
+	gs.logAction(room, p, fmt.Sprintf("Paid $%d to investigate: %s (%d%% chance)", amount, inc.Desc, chance))
+	if rand.Intn(100) >= chance {
+		if !p.IsBot {
+			gs.enqueueModal(p, "Trail Gone Cold", fmt.Sprintf("Your investigators spent %d credits but couldn't identify who was behind the incident.", amount))
+		}
+		return
+	}
+	gs.exposeInstigator(room, inc, p)
+}
+
+// exposeInstigator reveals who funded a contract. The first exposure of a contract
+// fines the instigator, costs them reputation everywhere and makes the news.
+func (gs *GameServer) exposeInstigator(room *Room, inc *BlackOpsIncident, by *Player) {
+	alreadyExposed := false
+	for _, other := range room.Incidents {
+		if other.ContractID == inc.ContractID {
+			alreadyExposed = alreadyExposed || other.Exposed
+			other.Exposed = true
+		}
+	}
+	for _, c := range room.PendingBlackOps {
+		if c != nil && c.ID == inc.ContractID {
+			c.Exposed = true
+		}
+	}
+	name := defaultStr(inc.InstigatorName, "a rival captain")
+	gs.logAction(room, by, fmt.Sprintf("Investigation exposed %s as the instigator", name))
+	inst := room.Players[inc.Instigator]
+	if alreadyExposed || inst == nil {
+		if !by.IsBot {
+			gs.enqueueModal(by, "Instigator Identified", fmt.Sprintf("Your investigators traced the incident to %s.", name))
+		}
+		return
+	}
+	fine := maxInt(exposureMinFine, inc.Price)
+	restitution := fine * exposureRestitutionPct / 100
+	by.Money += restitution
+	if !by.IsBot {
+		gs.enqueueModal(by, "Instigator Exposed", fmt.Sprintf("Your investigators traced the incident to %s. The Federation fined them %d credits and awarded you %d in restitution.", name, fine, restitution))
+	}
+	gs.logAction(room, inst, fmt.Sprintf("Exposed as black ops instigator: $%d fine", fine))
+	if !inst.IsBot {
+		gs.enqueueModal(inst, "Exposed!", fmt.Sprintf("%s's investigators traced a covert operation back to you. The Federation fined you %d credits.", by.Name, fine))
+	}
+	gs.adjustAllReputation(room, inst, -reputationExposedLoss, "exposed funding black ops")
+	room.News = append(room.News, NewsItem{Headline: inst.Name + " exposed funding sabotage against " + by.Name, Planet: inst.CurrentPlanet, TurnsRemaining: 3})
+	gs.chargeWithCredit(room, inst, fine, "black ops fines", inst.CurrentPlanet)
+}
+
+// handleBuyProtection hires a security detail that foils black ops for a number of turns.
+func (gs *GameServer) handleBuyProtection(room *Room, p *Player, turns int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if turns <= 0 {
+		return
+	}
+	turns = minInt(turns, protectionMaxTurns)
+	cost := turns * protectionCostPerTurn
+	if spendableFunds(p) < cost {
+		gs.enqueueModal(p, "Insufficient Funds", fmt.Sprintf("A %d-turn security detail costs %d credits.", turns, cost))
+		return
+	}
+	p.Money -= cost
+	p.ProtectedUntil = maxInt(p.ProtectedUntil, room.Turn) + turns
+	gs.logAction(room, p, fmt.Sprintf("Hired a security detail for %d turns ($%d, until turn %d)", turns, cost, p.ProtectedUntil))
+}
+
+// incidentsPayload lists the player's recorded setbacks, naming instigators once exposed.
+func incidentsPayload(room *Room, p *Player) []map[string]interface{} {
+	out := []map[string]interface{}{}
+	for _, inc := range room.Incidents {
+		if inc.Target != p.ID {
+			continue
+		}
+		entry := map[string]interface{}{
+			"id":           inc.ID,
+			"turn":         inc.Turn,
+			"desc":         inc.Desc,
+			"investigated": inc.Investigated,
+			"exposed":      inc.Exposed,
+		}
+		if inc.Exposed {
+			entry["instigator"] = inc.InstigatorName
+		}
+		out = append(out, entry)
+	}
+	return out
+}
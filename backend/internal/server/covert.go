@@ -0,0 +1,293 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Covert action kinds. Untargeted shady contracts keep an empty Kind.
+const (
+	covertTheft    = "theft"    // steal cargo from a rival's flagship
+	covertSabotage = "sabotage" // contaminate a rival's fuel
+	covertRumour   = "rumour"   // plant a fake market headline on a planet
+	covertRecon    = "recon"    // report a rival's cargo and heading
+)
+
+const fixerPlanetCount = 2
+
+// CovertAction is a fixer's price list entry.
+type CovertAction struct {
+	Kind    string `json:"kind"`
+	Price   int    `json:"price"`
+	FailPct int    `json:"failPct"`
+}
+
+var covertActions = []CovertAction{
+	{Kind: covertTheft, Price: 2500, FailPct: 25},
+	{Kind: covertSabotage, Price: 1500, FailPct: 15},
+	{Kind: covertRumour, Price: 2000, FailPct: 10},
+	{Kind: covertRecon, Price: 800, FailPct: 5},
+}
+
+func covertActionByKind(kind string) *CovertAction {
+	for i := range covertActions {
+		if covertActions[i].Kind == kind {
+			return &covertActions[i]
+		}
+	}
+	return nil
+}
+
+// pickFixerPlanets chooses where fixers sell covert actions; the lawless stations first.
+func pickFixerPlanets(names []string) []string {
+	stations, others := []string{}, []string{}
+	for _, n := range names {
+		if isStation(n) {
+			stations = append(stations, n)
+		} else {
+			others = append(others, n)
+		}
+	}
+	rand.Shuffle(len(stations), func(i, j int) { stations[i], stations[j] = stations[j], stations[i] })
+	rand.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+	out := append(stations, others...)
+	if len(out) > fixerPlanetCount {
+		out = out[:fixerPlanetCount]
+	}
+	sort.Strings(out)
+	return out
+}
+
+func roomHasFixer(room *Room, planet string) bool {
+	for _, n := range room.FixerPlanets {
+		if n == planet {
+			return true
+		}
+	}
+	return false
+}
+
+// handleHireCovertAction buys a covert action from the fixer at the flagship's
+// planet. It runs next turn through the same pending black ops queue.
+func (gs *GameServer) handleHireCovertAction(room *Room, p *Player, kind string, targetID PlayerID, planet, good, direction string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	action := covertActionByKind(kind)
+	if action == nil {
+		return
+	}
+	if p.InTransit || !roomHasFixer(room, p.CurrentPlanet) {
+		gs.enqueueModal(p, "No Fixer Here", "Covert work is only arranged in person with a fixer.")
+		return
+	}
+	contract := &BlackOpsContract{
+		ID:          randID(),
+		Kind:        kind,
+		Instigator:  p.ID,
+		TriggerTurn: room.Turn + 1,
+		Applied:     make(map[PlayerID]bool),
+		Price:       action.Price,
+	}
+	label := ""
+	if kind == covertRumour {
+		pl := room.Planets[planet]
+		if pl == nil || pl.Prices[good] <= 0 {
+			gs.enqueueModal(p, "Bad Target", "The fixer needs a planet and a good it trades to spread a rumour.")
+			return
+		}
+		if direction != "down" {
+			direction = "up"
+		}
+		contract.Planet, contract.Good, contract.Direction = planet, good, direction
+		label = fmt.Sprintf("a rumour that %s prices will go %s on %s", good, direction, planet)
+	} else {
+		target := room.Players[targetID]
+		if target == nil || target.ID == p.ID || target.Bankrupt {
+			gs.enqueueModal(p, "Bad Target", "The fixer needs an active rival to work against.")
+			return
+		}
+		contract.Target = target.ID
+		label = fmt.Sprintf("%s against %s", kind, target.Name)
+	}
+	if spendableFunds(p) < action.Price {
+		gs.enqueueModal(p, "Insufficient Funds", fmt.Sprintf("The fixer wants %d credits up front.", action.Price))
+		return
+	}
+	p.Money -= action.Price
+	room.PendingBlackOps = append(room.PendingBlackOps, contract)
+	gs.logAction(room, p, fmt.Sprintf("Hired a fixer for %s ($%d)", label, action.Price))
+	gs.enqueueModal(p, "Job Accepted", fmt.Sprintf("The fixer pockets %d credits. Expect word on %s next turn.", action.Price, label))
+}
+
+// resolveCovertActions runs targeted contracts whose trigger turn has come and
+// removes them from the pending queue.
+func (gs *GameServer) resolveCovertActions(room *Room) {
+	remaining := make([]*BlackOpsContract, 0, len(room.PendingBlackOps))
+	for _, c := range room.PendingBlackOps {
+		if c == nil {
+			continue
+		}
+		if c.Kind == "" || room.Turn < c.TriggerTurn {
+			remaining = append(remaining, c)
+			continue
+		}
+		gs.runCovertAction(room, c)
+	}
+	room.PendingBlackOps = remaining
+}
+
+func (gs *GameServer) runCovertAction(room *Room, c *BlackOpsContract) {
+	inst := room.Players[c.Instigator]
+	if inst == nil || inst.Bankrupt {
+		return
+	}
+	target := room.Players[c.Target]
+	if c.Kind != covertRumour && (target == nil || target.Bankrupt) {
+		gs.logAction(room, inst, "Covert job called off: the target is gone")
+		return
+	}
+	action := covertActionByKind(c.Kind)
+	if rand.Intn(100) < action.FailPct {
+		gs.logAction(room, inst, fmt.Sprintf("Covert %s failed", c.Kind))
+		if !inst.IsBot {
+			gs.enqueueModal(inst, "Job Botched", "The fixer's crew botched the job. Your money is gone.")
+		}
+		// A botched theft or sabotage leaves evidence the target can follow up
+		if target != nil && (c.Kind == covertTheft || c.Kind == covertSabotage) {
+			desc := "caught intruders near the ship"
+			gs.logAction(room, target, "Mysterious setback: "+desc)
+			if !target.IsBot {
+				gs.enqueueModal(target, "Intruders Spotted", "Dock security chased intruders away from your ship. Someone hired them.")
+			}
+			gs.botInvestigate(room, target, gs.recordIncident(room, c, target, desc))
+		}
+		return
+	}
+	switch c.Kind {
+	case covertTheft:
+		if gs.foilBlackOpsHit(room, c, target) {
+			return
+		}
+		gs.covertTheft(room, c, inst, target)
+	case covertSabotage:
+		if gs.foilBlackOpsHit(room, c, target) {
+			return
+		}
+		gs.covertSabotage(room, c, inst, target)
+	case covertRumour:
+		gs.covertRumour(room, c, inst)
+	case covertRecon:
+		gs.covertRecon(room, inst, target)
+	}
+}
+
+// covertTheft moves a random cargo lot from the target's flagship into the
+// instigator's hold; anything that doesn't fit is fenced at half cost.
+func (gs *GameServer) covertTheft(room *Room, c *BlackOpsContract, inst, target *Player) {
+	goods := sortedKeys(target.Inventory)
+	if len(goods) == 0 {
+		gs.logAction(room, inst, "Covert theft found an empty hold on "+target.Name+"'s ship")
+		if !inst.IsBot {
+			gs.enqueueModal(inst, "Empty Hold", target.Name+"'s hold was empty. The fixer keeps the fee.")
+		}
+		return
+	}
+	good := goods[rand.Intn(len(goods))]
+	qty := minInt(target.Inventory[good], 5+rand.Intn(11)) // 5-15 units
+	cost := target.InventoryAvgCost[good]
+	target.Inventory[good] -= qty
+	if target.Inventory[good] <= 0 {
+		delete(target.Inventory, good)
+		delete(target.InventoryAvgCost, good)
+	}
+	kept := minInt(qty, maxInt(0, inst.cargoCapacity()-inventoryTotal(inst.Inventory)))
+	if kept > 0 {
+		old := inst.Inventory[good]
+		inst.Inventory[good] = old + kept
+		inst.InventoryAvgCost[good] = old * inst.InventoryAvgCost[good] / (old + kept)
+	}
+	fenced := (qty - kept) * cost / 2
+	inst.Money += fenced
+	desc := fmt.Sprintf("lost %d %s cargo to thieves", qty, good)
+	gs.logAction(room, target, "Mysterious setback: "+desc)
+	if !target.IsBot {
+		gs.enqueueModal(target, "Cargo Stolen", fmt.Sprintf("Thieves slipped aboard overnight and made off with %d units of %s.", qty, good))
+	}
+	msg := fmt.Sprintf("Covert theft took %d %s from %s", qty, good, target.Name)
+	if fenced > 0 {
+		msg += fmt.Sprintf(" (%d fenced for $%d)", qty-kept, fenced)
+	}
+	gs.logAction(room, inst, msg)
+	if !inst.IsBot {
+		gs.enqueueModal(inst, "Job Done", msg+".")
+	}
+	gs.botInvestigate(room, target, gs.recordIncident(room, c, target, desc))
+}
+
+func (gs *GameServer) covertSabotage(room *Room, c *BlackOpsContract, inst, target *Player) {
+	loss := minInt(20+rand.Intn(21), maxInt(0, target.Fuel-5)) // 20-40 units, never below 5
+	target.Fuel -= loss
+	desc := fmt.Sprintf("lost %d fuel units to sabotage", loss)
+	gs.logAction(room, target, "Mysterious setback: "+desc)
+	if !target.IsBot {
+		gs.enqueueModal(target, "Fuel Sabotage", fmt.Sprintf("Someone contaminated your tanks. %d units of fuel had to be dumped.", loss))
+	}
+	gs.logAction(room, inst, fmt.Sprintf("Covert sabotage drained %d fuel from %s", loss, target.Name))
+	if !inst.IsBot {
+		gs.enqueueModal(inst, "Job Done", fmt.Sprintf("%s's ship lost %d units of fuel.", target.Name, loss))
+	}
+	gs.botInvestigate(room, target, gs.recordIncident(room, c, target, desc))
+}
+
+// covertRumour plants a fake headline that moves a price like real news would.
+func (gs *GameServer) covertRumour(room *Room, c *BlackOpsContract, inst *Player) {
+	rng, ok := priceRanges(room)[c.Good]
+	if !ok {
+		return
+	}
+	delta := maxInt(1, (rng[1]-rng[0])/4)
+	headline := c.Good + " prices surge on " + c.Planet
+	if c.Direction == "down" {
+		delta = -delta
+		headline = c.Good + " prices slump on " + c.Planet
+	}
+	room.News = append(room.News, NewsItem{Headline: headline, Planet: c.Planet, PriceDelta: map[string]int{c.Good: delta}, TurnsRemaining: 2 + rand.Intn(2)})
+	gs.logAction(room, inst, "Planted rumour: "+headline)
+	if !inst.IsBot {
+		gs.enqueueModal(inst, "Rumour Spreading", "The newsfeeds picked up your story: \""+headline+"\".")
+	}
+}
+
+// covertRecon reports the target's fleet, cargo and destinations to the instigator.
+func (gs *GameServer) covertRecon(room *Room, inst, target *Player) {
+	lines := []string{}
+	for _, s := range target.ships() {
+		where := "docked at " + s.CurrentPlanet
+		if s.InTransit || s.DestinationPlanet != "" {
+			where = "heading for " + defaultStr(s.DestinationPlanet, "parts unknown")
+		}
+		cargo := []string{}
+		for _, g := range sortedKeys(s.Inventory) {
+			cargo = append(cargo, fmt.Sprintf("%d %s", s.Inventory[g], g))
+		}
+		name := "Flagship"
+		if !target.isFlagship(s) {
+			name = s.Name
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s, carrying %s", name, where, defaultStr(strings.Join(cargo, ", "), "nothing")))
+	}
+	gs.logAction(room, inst, "Received reconnaissance on "+target.Name)
+	if !inst.IsBot {
+		gs.enqueueModal(inst, "Recon: "+target.Name, strings.Join(lines, "\n"))
+	}
+}
+
+// covertPayload describes the fixers and their price list for room state.
+func covertPayload(room *Room) map[string]interface{} {
+	return map[string]interface{}{
+		"fixerPlanets": append([]string(nil), room.FixerPlanets...),
+		"actions":      covertActions,
+	}
+}
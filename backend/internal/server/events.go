@@ -0,0 +1,527 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxEventLog = 50
+const eventChoiceTurns = 3 // turns a player has to answer an event's choices
+
+//go:embed events.json
+var eventCardsJSON []byte
+
+// EventCard is a random per-player event defined in events.json. Each turn every
+// active player rolls each enabled card once; if the conditions hold and the
+// effects apply, the log line is recorded and humans see the modal. Cards with
+// choices instead put the body to the player as a multi-choice modal. Cards with
+// oneIn 0 never roll and only fire as scheduled follow-ups.
+type EventCard struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	OneIn      int              `json:"oneIn"` // 1-in-n chance per player per turn
+	Conditions []EventCondition `json:"conditions,omitempty"`
+	Effects    []EventEffect    `json:"effects"`
+	Log        string           `json:"log"`  // {amount} and {good} are filled in from the effects
+	Body       string           `json:"body"` // modal text for humans; bots resolve silently
+	HumansOnly bool             `json:"humansOnly,omitempty"`
+	Choices    []EventChoice    `json:"choices,omitempty"`
+}
+
+// EventChoice is one branch of a card. Conditions are checked when offered and
+// again when chosen.
+type EventChoice struct {
+	ID          string           `json:"id"`
+	Label       string           `json:"label"`
+	Description string           `json:"description,omitempty"`
+	Cost        int              `json:"cost,omitempty"`
+	Conditions  []EventCondition `json:"conditions,omitempty"`
+	Unavailable string           `json:"unavailable,omitempty"` // why the conditions fail
+	Outcomes    []EventOutcome   `json:"outcomes"`
+}
+
+// EventOutcome is a weighted result of a choice. The first outcome is the hoped-for
+// one; its share of the weights is shown as the choice's success chance.
+type EventOutcome struct {
+	Weight   int           `json:"weight"`
+	Effects  []EventEffect `json:"effects,omitempty"`
+	Log      string        `json:"log"` // {cost} is filled in too
+	Body     string        `json:"body,omitempty"`
+	FollowUp string        `json:"followUp,omitempty"` // card fired later for the same player
+	Delay    int           `json:"delay,omitempty"`    // turns until the follow-up, at least 1
+}
+
+// ScheduledEvent is a follow-up card waiting for its turn.
+type ScheduledEvent struct {
+	Turn     int
+	PlayerID PlayerID
+	EventID  string
+}
+
+// EventCondition compares a player stat against a value, e.g. speedBonus > 0.
+type EventCondition struct {
+	Field string `json:"field"` // money, wealth, cargo, cargoSpace, fuel, speedBonus, capacityBonus, fuelCapacityBonus, atRepairDock, inTransit, guildMember
+	Op    string `json:"op"`    // >, >=, <, <=, ==, !=
+	Value int    `json:"value"`
+}
+
+// EventEffect changes the player. Min and Max bound the random amount; see applyEventEffect.
+type EventEffect struct {
+	Kind    string `json:"kind"`
+	Min     int    `json:"min,omitempty"`
+	Max     int    `json:"max,omitempty"`
+	Cause   string `json:"cause,omitempty"`   // overdraft cause for charges
+	Insured string `json:"insured,omitempty"` // policy that pays out on the loss: cargo or hull
+}
+
+// EventLogEntry records an event that fired.
+type EventLogEntry struct {
+	Turn       int      `json:"turn"`
+	EventID    string   `json:"eventId"`
+	Title      string   `json:"title"`
+	PlayerID   PlayerID `json:"playerId"`
+	PlayerName string   `json:"playerName"`
+	Text       string   `json:"text"`
+}
+
+var eventCards = mustLoadEventCards()
+
+// mustLoadEventCards decodes the embedded deck at startup; a broken events.json
+// stops the server rather than running a room with no events.
+func mustLoadEventCards() []EventCard {
+	cards, err := loadEventCards(eventCardsJSON)
+	if err != nil {
+		log.Fatalf("events.json: %v", err)
+	}
+	return cards
+}
+
+func loadEventCards(data []byte) ([]EventCard, error) {
+	var cards []EventCard
+	if err := json.Unmarshal(data, &cards); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, c := range cards {
+		if c.ID == "" || seen[c.ID] {
+			return nil, fmt.Errorf("card ids must be unique and non-empty (got %q)", c.ID)
+		}
+		seen[c.ID] = true
+	}
+	return cards, nil
+}
+
+func findEventCard(id string) *EventCard {
+	for i := range eventCards {
+		if eventCards[i].ID == id {
+			return &eventCards[i]
+		}
+	}
+	return nil
+}
+
+func (c *EventCard) choice(id string) *EventChoice {
+	for i := range c.Choices {
+		if c.Choices[i].ID == id {
+			return &c.Choices[i]
+		}
+	}
+	return nil
+}
+
+func eventStat(room *Room, p *Player, field string) int {
+	b2i := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	switch field {
+	case "money":
+		return p.Money
+	case "wealth":
+		return maxInt(0, p.Money+inventoryValue(p.Inventory, p.InventoryAvgCost))
+	case "cargo":
+		return inventoryTotal(p.Inventory)
+	case "cargoSpace":
+		return maxInt(0, p.cargoCapacity()-inventoryTotal(p.Inventory))
+	case "fuel":
+		return p.Fuel
+	case "speedBonus":
+		return p.SpeedBonus
+	case "capacityBonus":
+		return p.CapacityBonus
+	case "fuelCapacityBonus":
+		return p.FuelCapacityBonus
+	case "atRepairDock":
+		return b2i(repairDockAt(room, &p.Ship))
+	case "inTransit":
+		return b2i(p.InTransit)
+	case "guildMember":
+		return b2i(p.Guild != nil)
+	}
+	return 0
+}
+
+func (c EventCondition) holds(room *Room, p *Player) bool {
+	v := eventStat(room, p, c.Field)
+	switch c.Op {
+	case ">":
+		return v > c.Value
+	case ">=":
+		return v >= c.Value
+	case "<":
+		return v < c.Value
+	case "<=":
+		return v <= c.Value
+	case "==":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	}
+	return false
+}
+
+func randRange(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + rand.Intn(max-min+1)
+}
+
+// applyEventEffect changes the player's flagship and returns the amount and good for
+// the card's text, and the value lost for insurance. ok is false when the effect had
+// nothing to act on.
+func (gs *GameServer) applyEventEffect(room *Room, p *Player, e EventEffect) (amount int, good string, loss int, ok bool) {
+	switch e.Kind {
+	case "credit":
+		amount = randRange(e.Min, e.Max)
+		p.Money += amount
+	case "charge":
+		amount = randRange(e.Min, e.Max)
+		gs.chargeWithCredit(room, p, amount, e.Cause, p.CurrentPlanet)
+		loss = amount
+	case "quote":
+		// Flavor only: quotes a price without charging it
+		amount = randRange(e.Min, e.Max)
+	case "spoilCargo":
+		goods := sortedKeys(p.Inventory)
+		if len(goods) == 0 {
+			return 0, "", 0, false
+		}
+		good = goods[rand.Intn(len(goods))]
+		amount = minInt(p.Inventory[good], randRange(e.Min, e.Max))
+		loss = amount * p.InventoryAvgCost[good]
+		p.Inventory[good] -= amount
+		if p.Inventory[good] <= 0 {
+			delete(p.Inventory, good)
+			delete(p.InventoryAvgCost, good)
+		}
+	case "clearCargo":
+		loss = inventoryValue(p.Inventory, p.InventoryAvgCost)
+		p.Inventory = map[string]int{}
+		p.InventoryAvgCost = map[string]int{}
+	case "salvage":
+		ranges := priceRanges(room)
+		goods := make([]string, 0, len(ranges))
+		for g := range ranges {
+			goods = append(goods, g)
+		}
+		sort.Strings(goods)
+		if len(goods) == 0 {
+			return 0, "", 0, false
+		}
+		good = goods[rand.Intn(len(goods))]
+		amount = minInt(randRange(e.Min, e.Max), p.cargoCapacity()-inventoryTotal(p.Inventory))
+		if amount <= 0 {
+			return 0, "", 0, false
+		}
+		// Valued at the market mid-range
+		old := p.Inventory[good]
+		mid := (ranges[good][0] + ranges[good][1]) / 2
+		p.Inventory[good] = old + amount
+		p.InventoryAvgCost[good] = (old*p.InventoryAvgCost[good] + amount*mid) / (old + amount)
+	case "fuelLoss":
+		// Always leave at least 5 fuel
+		amount = minInt(randRange(e.Min, e.Max), p.Fuel-5)
+		if amount <= 0 {
+			return 0, "", 0, false
+		}
+		p.Fuel -= amount
+		loss = amount * fuelPriceAt(room, p.CurrentPlanet)
+	case "joinGuild":
+		// The choice's cost is the fee
+		if !joinGuild(room, p) {
+			return 0, "", 0, false
+		}
+	case "engineRepair":
+		// Min credits per speed unit repaired, up to Max units
+		if p.SpeedBonus <= 0 {
+			return 0, "", 0, false
+		}
+		amount = (1 + rand.Intn(minInt(p.SpeedBonus, e.Max))) * e.Min
+		gs.chargeWithCredit(room, p, amount, e.Cause, p.CurrentPlanet)
+		loss = amount
+	default:
+		return 0, "", 0, false
+	}
+	return amount, good, loss, true
+}
+
+// runEventCards fires the player's due follow-ups, then rolls every enabled card.
+// Stops early if an event bankrupts them.
+func (gs *GameServer) runEventCards(room *Room, p *Player) {
+	lapseEventChoices(room, p)
+	gs.runScheduledEvents(room, p)
+	for i := range eventCards {
+		card := &eventCards[i]
+		if p.Bankrupt {
+			return
+		}
+		if room.DisabledEvents[card.ID] || (card.HumansOnly && p.IsBot) || card.OneIn <= 0 || rand.Intn(card.OneIn) != 0 {
+			continue
+		}
+		gs.fireEventCard(room, p, card)
+	}
+}
+
+// lapseEventChoices drops event choices left unanswered past their deadline.
+func lapseEventChoices(room *Room, p *Player) {
+	kept := make([]ModalItem, 0, len(p.Modals))
+	for _, m := range p.Modals {
+		if m.Kind == "event-choice" && m.ExpiresTurn > 0 && room.Turn > m.ExpiresTurn {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	p.Modals = kept
+}
+
+func (gs *GameServer) runScheduledEvents(room *Room, p *Player) {
+	kept := room.ScheduledEvents[:0]
+	due := []string{}
+	for _, s := range room.ScheduledEvents {
+		if s.PlayerID == p.ID && s.Turn <= room.Turn {
+			due = append(due, s.EventID)
+			continue
+		}
+		kept = append(kept, s)
+	}
+	room.ScheduledEvents = kept
+	for _, id := range due {
+		card := findEventCard(id)
+		if p.Bankrupt || card == nil || room.DisabledEvents[id] {
+			continue
+		}
+		if !gs.fireEventCard(room, p, card) {
+			gs.logAction(room, p, card.Title+": nothing came of it")
+		}
+	}
+}
+
+// fireEventCard applies a card that came up for the player, or offers its choices.
+// Returns false if the conditions failed or an effect had nothing to act on.
+func (gs *GameServer) fireEventCard(room *Room, p *Player, card *EventCard) bool {
+	if !conditionsHold(room, p, card.Conditions) {
+		return false
+	}
+	if len(card.Choices) > 0 {
+		return gs.offerEventChoices(room, p, card)
+	}
+	amount, good, losses, ok := gs.applyEventEffects(room, p, card.Effects)
+	if !ok {
+		return false
+	}
+	fill := strings.NewReplacer("{amount}", strconv.Itoa(amount), "{good}", good)
+	gs.announceEvent(room, p, card, fill.Replace(card.Log), fill.Replace(card.Body))
+	gs.claimInsuranceLosses(room, p, losses, card.Title)
+	return true
+}
+
+// applyEventEffects applies effects in order, totalling insured losses by policy.
+func (gs *GameServer) applyEventEffects(room *Room, p *Player, effects []EventEffect) (amount int, good string, losses map[string]int, ok bool) {
+	losses = map[string]int{}
+	for _, e := range effects {
+		a, g, loss, applied := gs.applyEventEffect(room, p, e)
+		if !applied {
+			return 0, "", nil, false
+		}
+		amount, good = a, defaultStr(g, good)
+		if e.Insured != "" {
+			losses[e.Insured] += loss
+		}
+	}
+	return amount, good, losses, true
+}
+
+// announceEvent logs an event for the player, shows humans the modal and records
+// it in the room's event log.
+func (gs *GameServer) announceEvent(room *Room, p *Player, card *EventCard, text, body string) {
+	gs.logAction(room, p, text)
+	if !p.IsBot && body != "" {
+		gs.enqueueModal(p, card.Title, body)
+	}
+	room.EventLog = append(room.EventLog, EventLogEntry{Turn: room.Turn, EventID: card.ID, Title: card.Title, PlayerID: p.ID, PlayerName: p.Name, Text: text})
+	if len(room.EventLog) > maxEventLog {
+		room.EventLog = room.EventLog[len(room.EventLog)-maxEventLog:]
+	}
+}
+
+// offerEventChoices puts a card's choices to a human as a modal; bots pick one of
+// the choices open to them at random. Only one event choice is pending at a time.
+func (gs *GameServer) offerEventChoices(room *Room, p *Player, card *EventCard) bool {
+	if playerHasModalOfKind(p, "event-choice") {
+		return false
+	}
+	m := ModalItem{ID: randID(), Title: card.Title, Body: card.Body, Kind: "event-choice", EventID: card.ID, ExpiresTurn: room.Turn + eventChoiceTurns}
+	for _, ec := range card.Choices {
+		c := ModalChoice{ID: ec.ID, Label: ec.Label, Description: ec.Description, Cost: ec.Cost}
+		if len(ec.Outcomes) > 1 {
+			total := 0
+			for _, o := range ec.Outcomes {
+				total += maxInt(0, o.Weight)
+			}
+			if total > 0 {
+				c.Chance = maxInt(0, ec.Outcomes[0].Weight) * 100 / total
+			}
+		}
+		m.Choices = append(m.Choices, c)
+	}
+	if p.IsBot {
+		open := []ModalChoice{}
+		for _, c := range m.Choices {
+			if ok, _ := choiceAvailable(room, p, m, c); ok {
+				open = append(open, c)
+			}
+		}
+		if len(open) == 0 {
+			return false
+		}
+		gs.resolveEventChoice(room, p, m, open[rand.Intn(len(open))])
+		return true
+	}
+	if p.Bankrupt {
+		return false
+	}
+	p.Modals = append(p.Modals, m)
+	return true
+}
+
+// resolveEventChoice charges the choice's cost, rolls a weighted outcome, applies
+// it and schedules any follow-up. The choice has already been validated.
+func (gs *GameServer) resolveEventChoice(room *Room, p *Player, m ModalItem, c ModalChoice) {
+	card := findEventCard(m.EventID)
+	if card == nil {
+		return
+	}
+	ec := card.choice(c.ID)
+	if ec == nil {
+		return
+	}
+	if c.Cost > 0 {
+		p.Money -= c.Cost
+	}
+	o := pickOutcome(ec.Outcomes)
+	if o == nil {
+		return
+	}
+	amount, good, losses, ok := gs.applyEventEffects(room, p, o.Effects)
+	if !ok {
+		gs.announceEvent(room, p, card, fmt.Sprintf("%s (%s): nothing came of it", card.Title, ec.Label), "Nothing came of it.")
+		return
+	}
+	fill := strings.NewReplacer("{amount}", strconv.Itoa(amount), "{good}", good, "{cost}", strconv.Itoa(c.Cost))
+	gs.announceEvent(room, p, card, fill.Replace(o.Log), fill.Replace(o.Body))
+	gs.claimInsuranceLosses(room, p, losses, card.Title)
+	if o.FollowUp != "" && findEventCard(o.FollowUp) != nil {
+		room.ScheduledEvents = append(room.ScheduledEvents, ScheduledEvent{Turn: room.Turn + maxInt(1, o.Delay), PlayerID: p.ID, EventID: o.FollowUp})
+	}
+}
+
+func pickOutcome(outcomes []EventOutcome) *EventOutcome {
+	total := 0
+	for _, o := range outcomes {
+		total += maxInt(0, o.Weight)
+	}
+	if total == 0 {
+		return nil
+	}
+	roll := rand.Intn(total)
+	for i := range outcomes {
+		if roll -= maxInt(0, outcomes[i].Weight); roll < 0 {
+			return &outcomes[i]
+		}
+	}
+	return nil
+}
+
+func conditionsHold(room *Room, p *Player, conds []EventCondition) bool {
+	for _, c := range conds {
+		if !c.holds(room, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRoomOwner reports whether p created the private room and so may change its rules.
+// Public rooms have no owner.
+func isRoomOwner(room *Room, p *Player) bool {
+	return room.Private && room.CreatorID != "" && room.CreatorID == p.ID
+}
+
+// handleSetEventEnabled switches an event card on or off for the whole room. The
+// card deck is a room rule, so only the owner of a private room may change it.
+func (gs *GameServer) handleSetEventEnabled(room *Room, p *Player, eventID string, enabled bool) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	if !isRoomOwner(room, p) {
+		gs.enqueueModal(p, "Room Rules Locked", "Only the creator of a private room can switch event cards on or off.")
+		return
+	}
+	card := findEventCard(eventID)
+	if card == nil {
+		return
+	}
+	if room.DisabledEvents == nil {
+		room.DisabledEvents = map[string]bool{}
+	}
+	if room.DisabledEvents[eventID] == !enabled {
+		return
+	}
+	if enabled {
+		delete(room.DisabledEvents, eventID)
+	} else {
+		room.DisabledEvents[eventID] = true
+	}
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	gs.logGeneral(room, fmt.Sprintf("%s %s the %s event", p.Name, state, card.Title))
+}
+
+// eventsPayload lists the cards with their room toggles and the recent event log.
+func eventsPayload(room *Room) map[string]interface{} {
+	cards := make([]map[string]interface{}, 0, len(eventCards))
+	for _, c := range eventCards {
+		cards = append(cards, map[string]interface{}{
+			"id":         c.ID,
+			"title":      c.Title,
+			"oneIn":      c.OneIn,
+			"humansOnly": c.HumansOnly,
+			"followUp":   c.OneIn <= 0,
+			"enabled":    !room.DisabledEvents[c.ID],
+		})
+	}
+	return map[string]interface{}{
+		"cards": cards,
+		"log":   append([]EventLogEntry(nil), room.EventLog...),
+	}
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+)
+
+const maxFacilityCharge = 500     // ceiling owners may set on docking charges
+const fuelDepotDiscount = 3       // credits knocked off the local fuel price
+const fuelPriceFloor = 5          // same floor news events respect
+const tradeHubPullPct = 50        // how far a Trade Hub pulls prices toward the range midpoint
+const researchLabOfferBoost = 3   // upgrade offers are this many times as likely
+const researchLabDiscountPct = 20 // prototype discount on upgrade offers
+
+// planetHasFacility reports whether any facility of the given type operates at planet.
+func planetHasFacility(room *Room, planet, facilityType string) bool {
+	pl := room.Planets[planet]
+	if pl == nil {
+		return false
+	}
+	for _, f := range pl.Facilities {
+		if f != nil && f.Type == facilityType {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFacilityEffects layers facility bonuses on top of this turn's prices,
+// production and fuel price. Values are recomputed from baselines every turn,
+// so the effects never compound.
+func (gs *GameServer) applyFacilityEffects(room *Room, ranges map[string][2]int) {
+	for _, pl := range room.Planets {
+		if pl == nil {
+			continue
+		}
+		for _, f := range pl.Facilities {
+			if f == nil {
+				continue
+			}
+			switch f.Type {
+			case "Mining Station":
+				// +50% output on everything the planet already produces
+				for g, base := range pl.BaseProd {
+					if base > 0 {
+						pl.Prod[g] += (base + 1) / 2
+					}
+				}
+			case "Fuel Depot":
+				pl.FuelPrice = maxInt(fuelPriceFloor, pl.FuelPrice-fuelDepotDiscount)
+			case "Trade Hub":
+				// Deeper order books narrow the spread around fair value
+				for g, price := range pl.Prices {
+					r, ok := ranges[g]
+					if !ok {
+						continue
+					}
+					mid := (r[0] + r[1]) / 2
+					pl.Prices[g] = price + (mid-price)*tradeHubPullPct/100
+				}
+			}
+		}
+	}
+}
+
+// repairDockAt reports whether a docked ship at planet is serviced by a Repair Dock.
+func repairDockAt(room *Room, s *Ship) bool {
+	return !s.InTransit && planetHasFacility(room, s.CurrentPlanet, "Repair Dock")
+}
+
+// upgradeOfferOdds returns the 1-in-n chance for an upgrade offer, improved at Research Labs.
+func upgradeOfferOdds(room *Room, s *Ship, n int) int {
+	if !s.InTransit && planetHasFacility(room, s.CurrentPlanet, "Research Lab") {
+		return maxInt(1, n/researchLabOfferBoost)
+	}
+	return n
+}
+
+// upgradeOfferPrice applies the Research Lab prototype discount.
+func upgradeOfferPrice(room *Room, s *Ship, price int) int {
+	if !s.InTransit && planetHasFacility(room, s.CurrentPlanet, "Research Lab") {
+		return price * (100 - researchLabDiscountPct) / 100
+	}
+	return price
+}
+
+// handleSetFacilityCharge lets an owner tune the per-turn docking charge.
+func (gs *GameServer) handleSetFacilityCharge(room *Room, p *Player, facilityID string, charge int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	planet, f := findFacility(room, facilityID)
+	if f == nil || f.Owner != p.ID {
+		return
+	}
+	charge = clampInt(charge, 0, maxFacilityCharge)
+	if charge == f.UsageCharge {
+		return
+	}
+	f.UsageCharge = charge
+	gs.logAction(room, p, fmt.Sprintf("Set %s on %s usage charge to $%d", f.Type, planet.Name, charge))
+}
+
+// facilityEffect describes what a facility type does for its planet.
+func facilityEffect(facilityType string) string {
+	switch facilityType {
+	case "Mining Station":
+		return "Raises local production by half."
+	case "Refinery", "Factory":
+		return "Runs a production recipe chosen by its owner, turning local inputs into higher-value goods."
+	case "Fuel Depot":
+		return fmt.Sprintf("Cuts the local fuel price by %d credits.", fuelDepotDiscount)
+	case "Repair Dock":
+		return "Docked ships are safe from engine malfunctions."
+	case "Trade Hub":
+		return "Pulls local prices toward fair value."
+	case "Research Lab":
+		return fmt.Sprintf("Upgrade offers are %dx as frequent and %d%% cheaper for docked ships.", researchLabOfferBoost, researchLabDiscountPct)
+	case "Gate Station":
+		return fmt.Sprintf("Ships using any hyperlane through this chokepoint pay the owner a %d credit toll, plus any toll gate fee.", gateStationToll)
+	}
+	return ""
+}
+
+const federationBuybackPct = 60  // share of the purchase price the Federation pays when buying a facility back
+const facilityAbandonTurns = 10  // turns an owner may be away before their facilities are reclaimed
+const reclaimedAuctionRetry = 10 // turns before an unsold reclaimed facility is offered again
+
+// federationResaleValue is the depreciated price the Federation pays for a facility.
+func federationResaleValue(f *Facility) int {
+	return f.PurchasePrice * federationBuybackPct / 100
+}
+
+// reclaimedFacility returns a Federation-held facility that is due to be re-auctioned.
+func reclaimedFacility(room *Room) (*Planet, *Facility) {
+	for _, name := range planetNames(room.Planets) {
+		planet := room.Planets[name]
+		for _, f := range planet.Facilities {
+			if f != nil && f.Owner == "" && room.Turn >= f.NextAuctionTurn {
+				return planet, f
+			}
+		}
+	}
+	return nil, nil
+}
+
+// reclaimFacility returns a facility to the Federation. Every shareholder's stake is
+// bought out at the depreciated resale value, except the owner's when payOwner is false
+// (bankrupt or absent owners forfeit theirs, though other shareholders still get
+// their cut of accrued revenue). Returns the owner's payout.
+func (gs *GameServer) reclaimFacility(room *Room, planet *Planet, f *Facility, payOwner bool) int {
+	value := federationResaleValue(f)
+	if f.AccruedMoney > 0 {
+		ownerCut := gs.distributeFacilityRevenue(room, planet.Name, f, f.AccruedMoney)
+		if payOwner {
+			gs.creditPlayer(room, f.Owner, ownerCut)
+		}
+	}
+	shares := facilityShares(f)
+	holders := make([]PlayerID, 0, len(shares))
+	for pid := range shares {
+		holders = append(holders, pid)
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i] < holders[j] })
+	ownerPayout := 0
+	for _, pid := range holders {
+		n := shares[pid]
+		adjustFacilityInvestment(room, pid, -shareBookValue(f, n))
+		if pid == f.Owner && !payOwner {
+			continue
+		}
+		payout := value * n / facilityShareCount
+		gs.creditPlayer(room, pid, payout)
+		recordSaleFor(room, pid, payout, 0)
+		if pid == f.Owner {
+			ownerPayout = payout
+			continue
+		}
+		if pl := room.Players[pid]; pl != nil {
+			gs.logAction(room, pl, fmt.Sprintf("Federation bought out %d shares of %s on %s for $%d", n, f.Type, planet.Name, payout))
+		}
+	}
+	f.Owner = ""
+	f.OwnerName = "Federation"
+	f.AccruedMoney = 0
+	f.Shares = map[PlayerID]int{}
+	f.ShareAsks = nil
+	f.Recipe = ""
+	f.Storage = nil
+	f.NextAuctionTurn = room.Turn
+	return ownerPayout
+}
+
+// reclaimAbandonedFacilities returns facilities of bankrupt or long-absent owners to the
+// Federation so they can be re-auctioned. Called once per turn from the ticker.
+func (gs *GameServer) reclaimAbandonedFacilities(room *Room) {
+	for _, name := range planetNames(room.Planets) {
+		planet := room.Planets[name]
+		for _, f := range planet.Facilities {
+			if f == nil || f.Owner == "" {
+				continue
+			}
+			reason := ""
+			if owner := room.Players[f.Owner]; owner != nil {
+				if owner.Bankrupt {
+					reason = "bankruptcy"
+				}
+			} else if snap := room.Persist[f.Owner]; snap == nil || snap.Bankrupt || room.Turn-snap.LeftTurn >= facilityAbandonTurns {
+				reason = "abandonment"
+			}
+			if reason == "" {
+				continue
+			}
+			ownerName := f.OwnerName
+			gs.reclaimFacility(room, planet, f, false)
+			gs.logGeneral(room, fmt.Sprintf("Federation reclaimed %s's %s on %s after %s", ownerName, f.Type, name, reason))
+		}
+	}
+}
+
+// handleSellFacility sells a facility to the Federation (buyerID empty) or offers it to another player.
+func (gs *GameServer) handleSellFacility(room *Room, p *Player, facilityID string, buyerID PlayerID, price int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	planet, f := findFacility(room, facilityID)
+	if f == nil || f.Owner != p.ID {
+		return
+	}
+	if facilityListed(room, f.ID) {
+		gs.enqueueModal(p, "Facility Listed", "That facility is up for auction; cancel the listing first.")
+		return
+	}
+	if buyerID == "" {
+		payout := gs.reclaimFacility(room, planet, f, true)
+		gs.logAction(room, p, fmt.Sprintf("Sold %s on %s to the Federation for $%d", f.Type, planet.Name, payout))
+		gs.enqueueModal(p, "Facility Sold", fmt.Sprintf("The Federation bought your stake in the %s on %s for %d credits (%d%% of its purchase price).", f.Type, planet.Name, payout, federationBuybackPct))
+		gs.logGeneral(room, fmt.Sprintf("%s sold %s on %s back to the Federation", p.Name, f.Type, planet.Name))
+		return
+	}
+	buyer := room.Players[buyerID]
+	if buyer == nil || buyer == p || buyer.Bankrupt || price <= 0 {
+		gs.enqueueModal(p, "Buyer Unavailable", "That captain can't take over your facility right now.")
+		return
+	}
+	if buyer.IsBot {
+		// Bots take a deal at or under book value if they can keep a cash buffer
+		if price <= f.PurchasePrice && buyer.Money-200 >= price {
+			gs.completeFacilitySale(room, planet, f, p, buyer, price)
+			gs.logGeneral(room, fmt.Sprintf("%s sold %s on %s to %s", p.Name, f.Type, planet.Name, buyer.Name))
+		} else {
+			gs.enqueueModal(p, "Offer Declined", fmt.Sprintf("%s declined to buy your %s on %s for %d credits.", buyer.Name, f.Type, planet.Name, price))
+		}
+		return
+	}
+	gs.enqueueModal(buyer, "Facility For Sale", fmt.Sprintf("%s offers you their %s on %s for %d credits. %s Accept?", p.Name, f.Type, planet.Name, price, facilityEffect(f.Type)))
+	offer := &buyer.Modals[len(buyer.Modals)-1]
+	offer.Kind = "facility-offer"
+	offer.Price = price
+	offer.FacilityID = f.ID
+	offer.FacilityType = f.Type
+	offer.Planet = planet.Name
+	offer.SellerID = p.ID
+	gs.logAction(room, p, fmt.Sprintf("Offered %s on %s to %s for $%d", f.Type, planet.Name, buyer.Name, price))
+}
+
+// resolveFacilityOffer handles a buyer's answer to a facility-offer modal. Caller holds room.mu.
+func (gs *GameServer) resolveFacilityOffer(room *Room, p *Player, m ModalItem, accept bool) {
+	seller := room.Players[m.SellerID]
+	planet, f := findFacility(room, m.FacilityID)
+	if f == nil || seller == nil || f.Owner != m.SellerID {
+		if accept {
+			gs.enqueueModal(p, "Offer Expired", "That facility is no longer for sale.")
+		}
+		return
+	}
+	if facilityListed(room, f.ID) {
+		if accept {
+			gs.enqueueModal(p, "Offer Expired", "That facility has since been put up for auction.")
+		}
+		return
+	}
+	if !accept {
+		gs.enqueueModal(seller, "Offer Declined", fmt.Sprintf("%s declined to buy your %s on %s.", p.Name, f.Type, planet.Name))
+		return
+	}
+	if spendableFunds(p) < m.Price {
+		gs.enqueueModal(p, "Insufficient Funds", "You don't have enough credits to buy this facility.")
+		gs.enqueueModal(seller, "Sale Fell Through", fmt.Sprintf("%s couldn't afford your %s on %s.", p.Name, f.Type, planet.Name))
+		return
+	}
+	gs.completeFacilitySale(room, planet, f, seller, p, m.Price)
+	gs.logGeneral(room, fmt.Sprintf("%s sold %s on %s to %s", seller.Name, f.Type, planet.Name, p.Name))
+}
+
+// completeFacilitySale hands control and the seller's stake to the buyer. Minority
+// shareholders keep their shares; uncollected revenue is settled first.
+func (gs *GameServer) completeFacilitySale(room *Room, planet *Planet, f *Facility, seller, buyer *Player, price int) {
+	if f.AccruedMoney > 0 {
+		seller.Money += gs.distributeFacilityRevenue(room, planet.Name, f, f.AccruedMoney)
+		f.AccruedMoney = 0
+	}
+	kept := f.ShareAsks[:0]
+	for _, a := range f.ShareAsks {
+		if a.Seller != seller.ID {
+			kept = append(kept, a)
+		}
+	}
+	f.ShareAsks = kept
+	if n := facilityShares(f)[seller.ID]; n > 0 {
+		gs.transferShares(room, f, seller.ID, buyer.ID, n)
+	}
+	buyer.Money -= price
+	seller.Money += price
+	recordSale(seller, price, 0)
+	recordDeduction(buyer, deductFacilities, price)
+	f.Owner = buyer.ID
+	f.OwnerName = buyer.Name
+	gs.logAction(room, seller, fmt.Sprintf("Sold %s on %s to %s for $%d", f.Type, planet.Name, buyer.Name, price))
+	gs.logAction(room, buyer, fmt.Sprintf("Bought %s on %s from %s for $%d", f.Type, planet.Name, seller.Name, price))
+	gs.enqueueModal(seller, "Facility Sold", fmt.Sprintf("%s bought your %s on %s for %d credits.", buyer.Name, f.Type, planet.Name, price))
+	gs.enqueueModal(buyer, "Facility Acquired", fmt.Sprintf("You now own the %s on %s.", f.Type, planet.Name))
+}
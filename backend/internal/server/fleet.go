@@ -0,0 +1,241 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+const flagshipID = "flagship"
+const shipPrice = 15000 // cost of commissioning an additional hull
+const maxFleetSize = 4  // additional ships a player may own beyond the flagship
+const baseShipSpeed = 20
+
+// Ship holds per-vessel state. The flagship is embedded in Player so the
+// existing p.CurrentPlanet/p.Inventory/... accessors keep working; extra hulls
+// bought later live in Player.Fleet.
+type Ship struct {
+	ID                string         `json:"id"`
+	Name              string         `json:"name"`
+	CurrentPlanet     string         `json:"currentPlanet"`
+	DestinationPlanet string         `json:"destinationPlanet"`
+	Inventory         map[string]int `json:"inventory"`
+	InventoryAvgCost  map[string]int `json:"inventoryAvgCost"`
+	Fuel              int            `json:"fuel"`
+	// Transit state (server-only)
+	InTransit         bool     `json:"-"`
+	TransitFrom       string   `json:"-"`
+	TransitRemaining  int      `json:"-"` // units remaining to destination along straight line
+	TransitTotal      int      `json:"-"` // initial units at start of transit
+	TransitPath       []string `json:"-"` // hyperlane stops after TransitFrom, ending at the destination
+	CapacityBonus     int      `json:"-"`
+	SpeedBonus        int      `json:"-"`
+	FuelCapacityBonus int      `json:"-"`
+	Weapons           int      `json:"-"` // armament level, 0-maxArmamentLevel
+	Shields           int      `json:"-"`
+	Escort            bool     `json:"-"` // NPC escort hired, billed per turn in transit
+	// Automated trade route run by a hired captain (fleet ships only)
+	Route *TradeRoute `json:"-"`
+	// Passenger groups aboard, carried in berths separate from cargo
+	Passengers []*PassengerGroup `json:"-"`
+	// Remaining stops of a planned multi-hop route, next hop first
+	Waypoints []string `json:"-"`
+	// Destination the ship is being held in port for by closed hyperlanes
+	LaneHeldFor string `json:"-"`
+}
+
+func (s *Ship) cargoCapacity() int { return shipCapacity + s.CapacityBonus }
+func (s *Ship) maxFuel() int       { return fuelCapacity + s.FuelCapacityBonus }
+func (s *Ship) speed() int         { return baseShipSpeed + s.SpeedBonus }
+
+// ships returns the flagship followed by any additional fleet ships.
+func (p *Player) ships() []*Ship {
+	out := make([]*Ship, 0, 1+len(p.Fleet))
+	out = append(out, &p.Ship)
+	for _, s := range p.Fleet {
+		if s != nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// shipByID resolves a shipId from a client message. An empty id (or the
+// flagship id) selects the flagship so older clients keep working.
+func (p *Player) shipByID(id string) *Ship {
+	if id == "" || id == flagshipID {
+		return &p.Ship
+	}
+	for _, s := range p.Fleet {
+		if s != nil && s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (p *Player) isFlagship(s *Ship) bool { return s == &p.Ship }
+
+// shipLabel names a ship in player-facing text; the flagship is just "your ship".
+func (p *Player) shipLabel(s *Ship) string {
+	if s == nil || p.isFlagship(s) {
+		return "your ship"
+	}
+	return "the " + s.Name
+}
+
+// shipSuffix tags action log entries for non-flagship ships, e.g. " (Kestrel)".
+func shipSuffix(p *Player, s *Ship) string {
+	if s == nil || p.isFlagship(s) {
+		return ""
+	}
+	return " (" + s.Name + ")"
+}
+
+// dockedShips returns the player's ships currently docked (not in transit) at planet.
+func dockedShips(p *Player, planet string) []*Ship {
+	var out []*Ship
+	for _, s := range p.ships() {
+		if !s.InTransit && s.CurrentPlanet == planet {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func cloneShip(s *Ship) *Ship {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Inventory = cloneIntMap(s.Inventory)
+	c.InventoryAvgCost = cloneIntMap(s.InventoryAvgCost)
+	c.Route = cloneTradeRoute(s.Route)
+	c.Passengers = clonePassengers(s.Passengers)
+	c.Waypoints = append([]string(nil), s.Waypoints...)
+	c.TransitPath = append([]string(nil), s.TransitPath...)
+	return &c
+}
+
+func cloneFleet(in []*Ship) []*Ship {
+	if in == nil {
+		return nil
+	}
+	out := make([]*Ship, 0, len(in))
+	for _, s := range in {
+		if s != nil {
+			out = append(out, cloneShip(s))
+		}
+	}
+	return out
+}
+
+// totalCargoValue is the average-cost value of cargo across the flagship and fleet.
+func totalCargoValue(p *Player) int {
+	return inventoryValue(p.Inventory, p.InventoryAvgCost) + fleetCargoValue(p)
+}
+
+// fleetCargoValue sums the average-cost value of cargo carried by non-flagship ships.
+func fleetCargoValue(p *Player) int {
+	total := 0
+	for _, s := range p.Fleet {
+		if s != nil {
+			total += inventoryValue(s.Inventory, s.InventoryAvgCost)
+		}
+	}
+	return total
+}
+
+// handleBuyShip commissions a new hull at the planet where the flagship is docked.
+func (gs *GameServer) handleBuyShip(room *Room, p *Player, name string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if p.InTransit {
+		gs.enqueueModal(p, "In Transit", "Ships can only be commissioned while your flagship is docked.")
+		return
+	}
+	if len(p.Fleet) >= maxFleetSize {
+		gs.enqueueModal(p, "Fleet Limit", fmt.Sprintf("Federation registry caps private fleets at %d additional ships.", maxFleetSize))
+		return
+	}
+	if spendableFunds(p) < shipPrice {
+		gs.enqueueModal(p, "Insufficient Funds", fmt.Sprintf("A new ship costs %d credits.", shipPrice))
+		return
+	}
+	name = strings.TrimSpace(sanitizeAlphanumeric(name))
+	if name == "" {
+		name = fmt.Sprintf("Hauler %d", len(p.Fleet)+2)
+	}
+	s := &Ship{
+		ID:               "ship_" + randID(),
+		Name:             name,
+		CurrentPlanet:    p.CurrentPlanet,
+		Inventory:        map[string]int{},
+		InventoryAvgCost: map[string]int{},
+		Fuel:             fuelCapacity,
+	}
+	p.Money -= shipPrice
+	p.FleetInvestment += shipPrice
+	p.Fleet = append(p.Fleet, s)
+	gs.logAction(room, p, fmt.Sprintf("Commissioned %s at %s for $%d", s.Name, s.CurrentPlanet, shipPrice))
+	gs.enqueueModal(p, "Ship Commissioned", fmt.Sprintf("The %s is fuelled and waiting at %s.", s.Name, s.CurrentPlanet))
+}
+
+// shipPayload is the per-ship view sent to the owning player.
+func shipPayload(s *Ship) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                s.ID,
+		"name":              s.Name,
+		"currentPlanet":     s.CurrentPlanet,
+		"destinationPlanet": s.DestinationPlanet,
+		"inventory":         cloneIntMap(s.Inventory),
+		"inventoryAvgCost":  cloneIntMap(s.InventoryAvgCost),
+		"fuel":              s.Fuel,
+		"inTransit":         s.InTransit,
+		"transitFrom":       s.TransitFrom,
+		"transitRemaining":  s.TransitRemaining,
+		"transitTotal":      s.TransitTotal,
+		"transitPath":       append([]string(nil), s.TransitPath...),
+		"capacity":          s.cargoCapacity(),
+		"fuelCapacity":      s.maxFuel(),
+		"speedPerTurn":      s.speed(),
+		"cargoValue":        inventoryValue(s.Inventory, s.InventoryAvgCost),
+		"passengers":        clonePassengers(s.Passengers),
+		"berths":            s.berths(),
+		"weapons":           s.Weapons,
+		"shields":           s.Shields,
+		"escort":            s.Escort,
+		"waypoints":         append([]string(nil), s.Waypoints...),
+	}
+}
+
+func fleetPayload(room *Room, p *Player) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(p.Fleet))
+	for _, s := range p.Fleet {
+		if s == nil {
+			continue
+		}
+		entry := shipPayload(s)
+		if s.Route != nil {
+			entry["route"] = routeReportPayload(room, s)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// fleetPositions is the public view of a player's extra ships for map rendering.
+func fleetPositions(p *Player) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(p.Fleet))
+	for _, s := range p.Fleet {
+		if s == nil {
+			continue
+		}
+		out = append(out, map[string]interface{}{
+			"id":                s.ID,
+			"name":              s.Name,
+			"currentPlanet":     s.CurrentPlanet,
+			"destinationPlanet": s.DestinationPlanet,
+		})
+	}
+	return out
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Galaxy densities scale how far apart systems sit
+const (
+	densitySparse = "sparse"
+	densityNormal = "normal"
+	densityDense  = "dense"
+)
+
+const classicGalaxySize = 11 // the hand-made solar system from defaultPlanets
+const minGalaxySize = 6
+const maxGalaxySize = 40
+const baseUnitScale = 40.0  // travel units per normalized distance on a classic map
+const galaxyStationPct = 20 // share of generated systems that are stations
+const classicMinSpacing = 0.18
+
+// GalaxySettings choose the map for a room. Size 0 plays the classic solar system.
+type GalaxySettings struct {
+	Seed    int64  `json:"seed"`
+	Size    int    `json:"size"`
+	Density string `json:"density"`
+}
+
+// resourceProfiles bias which standard goods a generated system produces cheaply.
+var resourceProfiles = map[string][]string{
+	"Agricultural": {"Sky Kelp", "Moon Ferns", "Cosmic Coffee Beans"},
+	"Industrial":   {"Reticulated Splines", "Nano Lint", "Desalinated Sodium"},
+	"Consumer":     {"Zero-G Noodles", "Quantum Bubblegum"},
+	"Frontier":     {},
+}
+
+var (
+	systemSyllables = []string{"ka", "ri", "zo", "vel", "tor", "an", "mi", "quo", "sel", "dra", "ny", "ox", "pel", "thu", "ar", "is", "gen", "lo", "bex", "um"}
+	systemSuffixes  = []string{"", "", "", " Prime", " Major", " Minor", " IV", " VII"}
+	goodAdjectives  = []string{"Quantum", "Nebular", "Ionized", "Fractal", "Orbital", "Plasma", "Feral", "Gossamer", "Tachyon", "Velvet", "Chroma", "Hollow"}
+	goodNouns       = []string{"Pickles", "Marbles", "Lanterns", "Mittens", "Crumpets", "Harmonicas", "Yarn", "Teacups", "Pebbles", "Kazoos", "Figs", "Umbrellas"}
+)
+
+// normalizeGalaxy clamps settings to supported values, picking a seed if none was given.
+func normalizeGalaxy(s GalaxySettings) GalaxySettings {
+	if s.Size > 0 {
+		s.Size = clampInt(s.Size, minGalaxySize, maxGalaxySize)
+	}
+	switch s.Density {
+	case densitySparse, densityDense:
+	default:
+		s.Density = densityNormal
+	}
+	if s.Seed == 0 {
+		s.Seed = rand.Int63()
+	}
+	return s
+}
+
+// galaxyUnitScale stretches travel distances so bigger or sparser maps take longer to cross.
+func galaxyUnitScale(s GalaxySettings) float64 {
+	size := s.Size
+	if size <= 0 {
+		size = classicGalaxySize
+	}
+	scale := baseUnitScale * math.Sqrt(float64(size)/classicGalaxySize)
+	switch s.Density {
+	case densitySparse:
+		scale *= 1.3
+	case densityDense:
+		scale *= 0.75
+	}
+	return scale
+}
+
+func (room *Room) unitScale() float64 {
+	if room.UnitScale <= 0 {
+		return baseUnitScale
+	}
+	return room.UnitScale
+}
+
+// galaxyScaleFactor is how many classic solar systems the room's map is worth, at least 1.
+func galaxyScaleFactor(room *Room) int {
+	return maxInt(1, (len(room.Planets)+classicGalaxySize/2)/classicGalaxySize)
+}
+
+// priceRanges returns the room's price ranges: generated galaxies add their own goods.
+func priceRanges(room *Room) map[string][2]int {
+	if room == nil || room.PriceRanges == nil {
+		return defaultPriceRanges()
+	}
+	return room.PriceRanges
+}
+
+// placePlanets scatters names over the unit square keeping at least minDist apart
+// where it can.
+func placePlanets(rng *rand.Rand, names []string, minDist float64) map[string][2]float64 {
+	m := make(map[string][2]float64, len(names))
+	margin := 0.08 // keep away from edges
+	placed := make([][2]float64, 0, len(names))
+	for _, n := range names {
+		var x, y float64
+		ok := false
+		for tries := 0; tries < 200; tries++ {
+			x = margin + rng.Float64()*(1-2*margin)
+			y = margin + rng.Float64()*(1-2*margin)
+			good := true
+			for _, p := range placed {
+				if math.Hypot(p[0]-x, p[1]-y) < minDist {
+					good = false
+					break
+				}
+			}
+			if good {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			// fallback without spacing guarantee
+			x = margin + rng.Float64()*(1-2*margin)
+			y = margin + rng.Float64()*(1-2*margin)
+		}
+		placed = append(placed, [2]float64{x, y})
+		m[n] = [2]float64{x, y}
+	}
+	return m
+}
+
+// generateGalaxy builds a seeded galaxy of s.Size systems: names, resource profiles,
+// unique goods with their price ranges (including the classic map's), factions and
+// positions. The same settings always produce the same map.
+func generateGalaxy(s GalaxySettings) (planets map[string]*Planet, order []string, pos map[string][2]float64, ranges map[string][2]int) {
+	rng := rand.New(rand.NewSource(s.Seed))
+	ranges = defaultPriceRanges()
+	standard := []string{}
+	for _, goods := range resourceProfiles {
+		standard = append(standard, goods...)
+	}
+	standard = uniqueSorted(standard)
+
+	used := map[string]bool{}
+	for len(order) < s.Size {
+		name := ""
+		for i, n := 0, 2+rng.Intn(2); i < n; i++ {
+			name += systemSyllables[rng.Intn(len(systemSyllables))]
+		}
+		name = capitalizeFirst(name) + systemSuffixes[rng.Intn(len(systemSuffixes))]
+		if rng.Intn(100) < galaxyStationPct {
+			name = strings.TrimSpace(strings.Fields(name)[0]) + " Station"
+		}
+		if used[name] {
+			continue
+		}
+		used[name] = true
+		order = append(order, name)
+	}
+
+	profileNames := sortedProfileNames()
+	uniqueBy := map[string][]string{}
+	allUnique := []string{}
+	for _, n := range order {
+		for i := 0; i < 2; i++ {
+			g := goodAdjectives[rng.Intn(len(goodAdjectives))] + " " + goodNouns[rng.Intn(len(goodNouns))]
+			if _, taken := ranges[g]; taken {
+				continue
+			}
+			min := 25 + rng.Intn(21) // unique goods trade higher than staples
+			ranges[g] = [2]int{min, min + 16 + rng.Intn(7)}
+			uniqueBy[n] = append(uniqueBy[n], g)
+			allUnique = append(allUnique, g)
+		}
+	}
+	// The classic solar system's unique goods are spread across the map too, so
+	// production recipes and faction contraband have sources
+	classic := []string{}
+	for g := range defaultPriceRanges() {
+		if !containsGood(standard, g) {
+			classic = append(classic, g)
+		}
+	}
+	sort.Strings(classic)
+	hosts := rng.Perm(len(order))
+	for i, g := range classic {
+		n := order[hosts[i%len(hosts)]]
+		uniqueBy[n] = append(uniqueBy[n], g)
+		allUnique = append(allUnique, g)
+	}
+
+	planets = map[string]*Planet{}
+	for _, n := range order {
+		profile := profileNames[rng.Intn(len(profileNames))]
+		goods := map[string]int{}
+		prices := map[string]int{}
+		prod := map[string]int{}
+		trend := map[string]int{}
+		for _, g := range standard {
+			r := ranges[g]
+			goods[g] = 20 + rng.Intn(30)
+			prices[g] = r[0] + rng.Intn(r[1]-r[0]+1)
+			prod[g] = 1 + rng.Intn(3) // 1-3 per turn
+			if containsGood(resourceProfiles[profile], g) {
+				// Home industry: plentiful and cheap
+				goods[g] += 20
+				prices[g] = r[0] + rng.Intn((r[1]-r[0])/3+1)
+				prod[g] = 4 + rng.Intn(4) // 4-7 per turn
+			}
+		}
+		for _, g := range uniqueBy[n] {
+			goods[g] = 10 + rng.Intn(20)
+			prod[g] = 1 + rng.Intn(3)
+			if profile == "Frontier" {
+				prod[g] += 2
+			}
+		}
+		// Every good has a price everywhere so it can be sold anywhere
+		for _, g := range allUnique {
+			if _, ok := prices[g]; !ok {
+				r := ranges[g]
+				prices[g] = r[0] + rng.Intn(r[1]-r[0]+1)
+			}
+		}
+		for g := range prices {
+			trend[g] = 0
+		}
+		fp := 8 + rng.Intn(5) // 8..12
+		planets[n] = &Planet{Name: n, Profile: profile, Goods: goods, Prices: prices, Prod: prod, BasePrices: cloneIntMap(prices), BaseProd: cloneIntMap(prod), PriceTrend: trend, FuelPrice: fp, BaseFuelPrice: fp, Facilities: []*Facility{}}
+	}
+
+	minDist := classicMinSpacing * math.Sqrt(classicGalaxySize/float64(s.Size))
+	pos = placePlanets(rng, order, minDist)
+	assignFactions(planets, pos)
+	return planets, order, pos, ranges
+}
+
+func sortedProfileNames() []string {
+	out := make([]string, 0, len(resourceProfiles))
+	for n := range resourceProfiles {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func uniqueSorted(in []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// galaxyPayload describes the room's map settings and each system's profile.
+func galaxyPayload(room *Room) map[string]interface{} {
+	profiles := map[string]string{}
+	for n, pl := range room.Planets {
+		if pl != nil && pl.Profile != "" {
+			profiles[n] = pl.Profile
+		}
+	}
+	return map[string]interface{}{
+		"seed":     room.Galaxy.Seed,
+		"size":     len(room.Planets),
+		"density":  room.Galaxy.Density,
+		"profiles": profiles,
+	}
+}
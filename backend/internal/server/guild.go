@@ -0,0 +1,189 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+const guildHallCount = 2
+const guildJoinFee = 4000    // at a guild hall; invitations may quote less
+const guildDues = 600        // per dues period
+const guildDuesInterval = 10 // turns between dues
+const guildGraceTurns = 3    // turns in arrears before expulsion
+const guildFacilityDiscountPct = 25
+const guildNewsLead = 1 // turns members hear of price and production news before it is public
+
+// GuildMembership is a player's standing with the Galactic Traders Guild.
+type GuildMembership struct {
+	JoinedTurn   int
+	DuesTurn     int // next turn dues are collected
+	ArrearsSince int // turn dues first went unpaid, 0 when paid up
+}
+
+func cloneGuild(g *GuildMembership) *GuildMembership {
+	if g == nil {
+		return nil
+	}
+	c := *g
+	return &c
+}
+
+// pickGuildHalls chooses the planets where captains can join the guild in person.
+func pickGuildHalls(names []string) []string {
+	out := append([]string(nil), names...)
+	rand.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	if len(out) > guildHallCount {
+		out = out[:guildHallCount]
+	}
+	sort.Strings(out)
+	return out
+}
+
+func roomHasGuildHall(room *Room, planet string) bool {
+	for _, n := range room.GuildHalls {
+		if n == planet {
+			return true
+		}
+	}
+	return false
+}
+
+// joinGuild enrols the player; the caller has already taken the fee. Returns false
+// for existing members.
+func joinGuild(room *Room, p *Player) bool {
+	if p.Guild != nil {
+		return false
+	}
+	p.Guild = &GuildMembership{JoinedTurn: room.Turn, DuesTurn: room.Turn + guildDuesInterval}
+	return true
+}
+
+// handleJoinGuild buys membership at a guild hall where the flagship is docked.
+func (gs *GameServer) handleJoinGuild(room *Room, p *Player) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if p.Guild != nil || p.InTransit || !roomHasGuildHall(room, p.CurrentPlanet) {
+		return
+	}
+	if spendableFunds(p) < guildJoinFee {
+		gs.enqueueModal(p, "Insufficient Funds", fmt.Sprintf("Guild membership costs %d credits.", guildJoinFee))
+		return
+	}
+	p.Money -= guildJoinFee
+	joinGuild(room, p)
+	gs.logAction(room, p, fmt.Sprintf("Joined the Galactic Traders Guild for $%d at %s", guildJoinFee, p.CurrentPlanet))
+	gs.enqueueModal(p, "Welcome to the Guild", fmt.Sprintf("You are now a member of the Galactic Traders Guild. Members pay %d%% less in facility fees, hear of market news a turn early and see who is bidding what in English auctions. Dues of %d credits are collected every %d turns.", guildFacilityDiscountPct, guildDues, guildDuesInterval))
+}
+
+// handleLeaveGuild gives up membership. Fees already paid are not refunded.
+func (gs *GameServer) handleLeaveGuild(room *Room, p *Player) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if p.Guild == nil {
+		return
+	}
+	p.Guild = nil
+	gs.logAction(room, p, "Resigned from the Galactic Traders Guild")
+}
+
+// collectGuildDues takes dues from members when due. Members who can't pay fall
+// into arrears and are expelled after guildGraceTurns.
+func (gs *GameServer) collectGuildDues(room *Room) {
+	for _, p := range room.Players {
+		g := p.Guild
+		if g == nil || p.Bankrupt || room.Turn < g.DuesTurn {
+			continue
+		}
+		if spendableFunds(p) >= guildDues {
+			p.Money -= guildDues
+			g.DuesTurn = room.Turn + guildDuesInterval
+			g.ArrearsSince = 0
+			gs.logAction(room, p, fmt.Sprintf("Guild dues paid: $%d", guildDues))
+			continue
+		}
+		if g.ArrearsSince == 0 {
+			g.ArrearsSince = room.Turn
+			gs.logAction(room, p, "Guild dues unpaid")
+			if !p.IsBot {
+				gs.enqueueModal(p, "Guild Dues Overdue", fmt.Sprintf("You couldn't cover your %d credits of guild dues. Pay within %d turns or be expelled; the guild will try again each turn.", guildDues, guildGraceTurns))
+			}
+			continue
+		}
+		if room.Turn-g.ArrearsSince >= guildGraceTurns {
+			p.Guild = nil
+			gs.logAction(room, p, "Expelled from the Galactic Traders Guild for unpaid dues")
+			gs.logGeneral(room, fmt.Sprintf("%s was expelled from the Galactic Traders Guild", p.Name))
+			if !p.IsBot {
+				gs.enqueueModal(p, "Expelled from the Guild", "The Galactic Traders Guild has revoked your membership for unpaid dues. You may rejoin at a guild hall.")
+			}
+		}
+	}
+}
+
+// guildFacilityCharge is a facility's usage charge after any member discount.
+func guildFacilityCharge(p *Player, charge int) int {
+	if p.Guild == nil {
+		return charge
+	}
+	return charge * (100 - guildFacilityDiscountPct) / 100
+}
+
+// guildAuctionIntel gives members what the public auction view doesn't: the rival
+// bids behind the leader of an English auction, who is bidding (but not how much)
+// in sealed and Vickrey auctions, and where a Dutch price goes next.
+func guildAuctionIntel(room *Room, a *FederationAuction, viewer *Player) map[string]interface{} {
+	if viewer == nil || viewer.Guild == nil {
+		return nil
+	}
+	switch a.Format {
+	case auctionEnglish:
+		ladder := []map[string]interface{}{}
+		for _, b := range rankedBids(a) {
+			if b.Player == viewer.ID {
+				continue
+			}
+			if pp := room.Players[b.Player]; pp != nil {
+				ladder = append(ladder, map[string]interface{}{"name": pp.Name, "bid": b.Amount})
+			}
+		}
+		return map[string]interface{}{"ladder": ladder}
+	case auctionSealed, auctionVickrey:
+		rivals := []string{}
+		for _, b := range rankedBids(a) {
+			if pp := room.Players[b.Player]; pp != nil && b.Player != viewer.ID {
+				rivals = append(rivals, pp.Name)
+			}
+		}
+		sort.Strings(rivals)
+		return map[string]interface{}{"rivals": rivals}
+	case auctionDutch:
+		next := maxInt(a.ReservePrice, a.CurrentPrice-a.MinIncrement)
+		drops := 0
+		if a.MinIncrement > 0 {
+			drops = (a.CurrentPrice - a.ReservePrice + a.MinIncrement - 1) / a.MinIncrement
+		}
+		return map[string]interface{}{"nextPrice": next, "turnsToFloor": drops}
+	}
+	return nil
+}
+
+// guildPayload describes the guild for the `you` payload.
+func guildPayload(room *Room, p *Player) map[string]interface{} {
+	out := map[string]interface{}{
+		"halls":       append([]string(nil), room.GuildHalls...),
+		"joinFee":     guildJoinFee,
+		"dues":        guildDues,
+		"duesEvery":   guildDuesInterval,
+		"discountPct": guildFacilityDiscountPct,
+		"member":      p.Guild != nil,
+	}
+	if g := p.Guild; g != nil {
+		out["joinedTurn"] = g.JoinedTurn
+		out["duesTurn"] = g.DuesTurn
+		if g.ArrearsSince > 0 {
+			out["expelTurn"] = g.ArrearsSince + guildGraceTurns
+		}
+	}
+	return out
+}
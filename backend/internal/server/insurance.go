@@ -0,0 +1,215 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Insurance policies
+const (
+	insuranceCargo = "cargo" // cargo lost to asteroids, spoilage and pirates
+	insuranceHull  = "hull"  // repairs and fuel lost to malfunctions and accidents
+)
+
+const insuranceCargoRateBP = 120  // cargo premium per turn in basis points of insured cargo value
+const insuranceHullBase = 8       // hull premium per turn for the flagship
+const insuranceHullPerSpeed = 4   // tuned engines fail more often
+const insuranceHullPerShip = 3    // each fleet ship beyond the flagship
+const insuranceMinPremium = 5     // per policy per turn
+const insuranceRiskLoadingPct = 4 // premium percent per mille of leg risk above the base
+const insuranceClaimLoadingPct = 25
+const insuranceClaimMemory = 30 // turns a claim counts against the premium
+const insuranceCoveragePct = 80 // share of each loss paid out
+const insuranceDeductible = 50
+const insuranceMaxClaim = 20000
+const maxInsuranceClaims = 10 // claim history kept per player
+
+// Insurance holds a player's policies and claim history. Policies cover every ship.
+type Insurance struct {
+	Cargo        bool
+	Hull         bool
+	CargoSince   int // losses are covered from the turn after purchase
+	HullSince    int
+	Claims       []InsuranceClaim
+	PremiumsPaid int
+}
+
+// InsuranceClaim is a payout for a covered loss.
+type InsuranceClaim struct {
+	Turn   int    `json:"turn"`
+	Policy string `json:"policy"`
+	Cause  string `json:"cause"`
+	Loss   int    `json:"loss"`
+	Paid   int    `json:"paid"`
+}
+
+func cloneInsurance(in *Insurance) *Insurance {
+	if in == nil {
+		return nil
+	}
+	c := *in
+	c.Claims = append([]InsuranceClaim(nil), in.Claims...)
+	return &c
+}
+
+func (in *Insurance) covers(policy string, turn int) bool {
+	if in == nil {
+		return false
+	}
+	switch policy {
+	case insuranceCargo:
+		return in.Cargo && turn > in.CargoSince
+	case insuranceHull:
+		return in.Hull && turn > in.HullSince
+	}
+	return false
+}
+
+func (in *Insurance) recentClaims(turn int) int {
+	n := 0
+	if in != nil {
+		for _, c := range in.Claims {
+			if turn-c.Turn < insuranceClaimMemory {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// routeRisk is the worst per-mille pirate risk on any leg the player's ships are
+// flying, doubled across hazardous lanes. Docked fleets carry the base risk.
+func routeRisk(room *Room, p *Player) int {
+	worst := encounterBaseChance
+	for _, ship := range p.ships() {
+		if !ship.InTransit {
+			continue
+		}
+		risk := legRisk(room, ship.TransitFrom, ship.DestinationPlanet)
+		if pathHazardous(room, ship) {
+			risk *= 2
+		}
+		worst = maxInt(worst, risk)
+	}
+	return worst
+}
+
+// insurancePremium prices a policy for this turn from what it covers, the route
+// risk and the player's recent claims.
+func insurancePremium(room *Room, p *Player, policy string) int {
+	base := 0
+	switch policy {
+	case insuranceCargo:
+		for _, ship := range p.ships() {
+			base += inventoryValue(ship.Inventory, ship.InventoryAvgCost)
+		}
+		base = base * insuranceCargoRateBP / 10000
+	case insuranceHull:
+		base = insuranceHullBase + p.SpeedBonus*insuranceHullPerSpeed + (len(p.ships())-1)*insuranceHullPerShip
+	}
+	loading := 100 + (routeRisk(room, p)-encounterBaseChance)*insuranceRiskLoadingPct + p.Insurance.recentClaims(room.Turn)*insuranceClaimLoadingPct
+	return maxInt(insuranceMinPremium, base*loading/100)
+}
+
+// handleSetInsurance buys or cancels a policy. Cover starts next turn.
+func (gs *GameServer) handleSetInsurance(room *Room, p *Player, policy string, enabled bool) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if policy != insuranceCargo && policy != insuranceHull {
+		return
+	}
+	if p.Insurance == nil {
+		p.Insurance = &Insurance{}
+	}
+	in := p.Insurance
+	active, since := &in.Cargo, &in.CargoSince
+	if policy == insuranceHull {
+		active, since = &in.Hull, &in.HullSince
+	}
+	if *active == enabled {
+		return
+	}
+	*active = enabled
+	if enabled {
+		*since = room.Turn
+		gs.logAction(room, p, fmt.Sprintf("Took out %s insurance at $%d per turn", policy, insurancePremium(room, p, policy)))
+	} else {
+		gs.logAction(room, p, fmt.Sprintf("Cancelled %s insurance", policy))
+	}
+}
+
+// chargeInsurancePremiums bills every active policy for the turn.
+func (gs *GameServer) chargeInsurancePremiums(room *Room) {
+	for _, p := range room.Players {
+		in := p.Insurance
+		if in == nil || p.Bankrupt {
+			continue
+		}
+		total := 0
+		for _, policy := range []string{insuranceCargo, insuranceHull} {
+			if (policy == insuranceCargo && in.Cargo) || (policy == insuranceHull && in.Hull) {
+				total += insurancePremium(room, p, policy)
+			}
+		}
+		if total == 0 {
+			continue
+		}
+		in.PremiumsPaid += total
+		gs.logAction(room, p, fmt.Sprintf("Insurance premiums: $%d", total))
+		gs.chargeWithCredit(room, p, total, "insurance premiums", p.CurrentPlanet)
+	}
+}
+
+// claimInsurance pays out on a covered loss. Returns the payout.
+func (gs *GameServer) claimInsurance(room *Room, p *Player, policy string, loss int, cause string) int {
+	if loss <= 0 || p.Bankrupt || !p.Insurance.covers(policy, room.Turn) {
+		return 0
+	}
+	paid := minInt(insuranceMaxClaim, loss*insuranceCoveragePct/100-insuranceDeductible)
+	if paid <= 0 {
+		return 0
+	}
+	p.Money += paid
+	in := p.Insurance
+	in.Claims = append(in.Claims, InsuranceClaim{Turn: room.Turn, Policy: policy, Cause: cause, Loss: loss, Paid: paid})
+	if len(in.Claims) > maxInsuranceClaims {
+		in.Claims = in.Claims[len(in.Claims)-maxInsuranceClaims:]
+	}
+	gs.logAction(room, p, fmt.Sprintf("Insurance claim (%s, %s): +$%d on a $%d loss", policy, cause, paid, loss))
+	if !p.IsBot {
+		gs.enqueueModal(p, "Insurance Claim Paid", fmt.Sprintf("Your %s policy paid %d credits toward the %d-credit loss from %s. Recent claims raise your premiums.", policy, paid, loss, cause))
+	}
+	return paid
+}
+
+// claimInsuranceLosses files a claim for each policy in losses.
+func (gs *GameServer) claimInsuranceLosses(room *Room, p *Player, losses map[string]int, cause string) {
+	policies := make([]string, 0, len(losses))
+	for policy := range losses {
+		policies = append(policies, policy)
+	}
+	sort.Strings(policies)
+	for _, policy := range policies {
+		gs.claimInsurance(room, p, policy, losses[policy], cause)
+	}
+}
+
+// insurancePayload quotes both policies and lists the player's cover and claims.
+func insurancePayload(room *Room, p *Player) map[string]interface{} {
+	in := p.Insurance
+	out := map[string]interface{}{
+		"cargoPremium": insurancePremium(room, p, insuranceCargo),
+		"hullPremium":  insurancePremium(room, p, insuranceHull),
+		"routeRisk":    routeRisk(room, p),
+		"coveragePct":  insuranceCoveragePct,
+		"deductible":   insuranceDeductible,
+	}
+	if in != nil {
+		out["cargo"] = in.Cargo
+		out["hull"] = in.Hull
+		out["recentClaims"] = in.recentClaims(room.Turn)
+		out["claims"] = append([]InsuranceClaim(nil), in.Claims...)
+		out["premiumsPaid"] = in.PremiumsPaid
+	}
+	return out
+}
@@ -0,0 +1,354 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Hyperlane kinds
+const (
+	laneSafe      = "safe"
+	laneHazardous = "hazardous" // pirates hunt here; encounter odds are doubled
+	laneToll      = "toll"      // a Federation toll gate charges ships on departure
+)
+
+const laneExtraLinkPct = 40 // chance each planet gets a shortcut beyond the spanning tree
+const laneHazardousPct = 20
+const laneTollPct = 15
+const laneTollMin = 40
+const laneTollMax = 120
+const laneClosureChance = 12 // 1-in-n news items close a hyperlane
+const gateStationToll = 25   // charged per lane touching a Gate Station, paid to its owner
+
+// Lane is a hyperlane between two planets. Ships only travel along lanes.
+type Lane struct {
+	ID    string `json:"id"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Kind  string `json:"kind"`
+	Units int    `json:"units"`
+	Toll  int    `json:"toll,omitempty"`
+}
+
+func (l *Lane) other(planet string) string {
+	if l.A == planet {
+		return l.B
+	}
+	return l.A
+}
+
+// positionUnits scales a normalized distance (~0..1.4) to travel units, rounding up; at least 1.
+func positionUnits(a, b [2]float64, scale float64) int {
+	return maxInt(1, int(math.Ceil(math.Hypot(a[0]-b[0], a[1]-b[1])*scale)))
+}
+
+// generateLanes links every planet with a minimum spanning tree, then gives some
+// planets a lane to their nearest unlinked neighbour so many trips have an
+// alternative while the rest of the map keeps its chokepoints.
+func generateLanes(rng *rand.Rand, names []string, pos map[string][2]float64, scale float64) []*Lane {
+	if len(names) < 2 {
+		return nil
+	}
+	lanes := []*Lane{}
+	linked := map[string]bool{}
+	link := func(a, b string) {
+		if a > b {
+			a, b = b, a
+		}
+		if linked[a+"|"+b] {
+			return
+		}
+		linked[a+"|"+b] = true
+		l := &Lane{ID: a + "|" + b, A: a, B: b, Kind: laneSafe, Units: positionUnits(pos[a], pos[b], scale)}
+		switch r := rng.Intn(100); {
+		case r < laneHazardousPct:
+			l.Kind = laneHazardous
+		case r < laneHazardousPct+laneTollPct:
+			l.Kind = laneToll
+			l.Toll = laneTollMin + rng.Intn(laneTollMax-laneTollMin+1)
+		}
+		lanes = append(lanes, l)
+	}
+	// Prim's algorithm over straight-line distance
+	inTree := map[string]bool{names[0]: true}
+	for len(inTree) < len(names) {
+		bestA, bestB, best := "", "", math.MaxInt
+		for _, a := range names {
+			if !inTree[a] {
+				continue
+			}
+			for _, b := range names {
+				if inTree[b] {
+					continue
+				}
+				if d := positionUnits(pos[a], pos[b], scale); d < best {
+					bestA, bestB, best = a, b, d
+				}
+			}
+		}
+		inTree[bestB] = true
+		link(bestA, bestB)
+	}
+	for _, a := range names {
+		nearest, best := "", math.MaxInt
+		for _, b := range names {
+			if a == b || linked[a+"|"+b] || linked[b+"|"+a] {
+				continue
+			}
+			if d := positionUnits(pos[a], pos[b], scale); d < best {
+				nearest, best = b, d
+			}
+		}
+		if nearest != "" && rng.Intn(100) < laneExtraLinkPct {
+			link(a, nearest)
+		}
+	}
+	sort.Slice(lanes, func(i, j int) bool { return lanes[i].ID < lanes[j].ID })
+	return lanes
+}
+
+func findLane(room *Room, a, b string) *Lane {
+	for _, l := range room.Lanes {
+		if (l.A == a && l.B == b) || (l.A == b && l.B == a) {
+			return l
+		}
+	}
+	return nil
+}
+
+// laneClosed reports whether a news event has shut the lane.
+func laneClosed(room *Room, l *Lane) bool {
+	for _, n := range room.News {
+		if n.ClosedLane == l.ID && n.TurnsRemaining > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// laneEdges lists the lanes leaving planet. Rooms without a lane graph fall back
+// to straight-line links between every pair of planets.
+func laneEdges(room *Room, planet string, openOnly bool) []*Lane {
+	out := []*Lane{}
+	if len(room.Lanes) == 0 {
+		for _, n := range planetNames(room.Planets) {
+			if n != planet {
+				out = append(out, &Lane{A: planet, B: n, Kind: laneSafe, Units: distanceUnits(room, planet, n)})
+			}
+		}
+		return out
+	}
+	for _, l := range room.Lanes {
+		if (l.A == planet || l.B == planet) && !(openOnly && laneClosed(room, l)) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// lanePath finds the shortest path along lanes, returning the planets after from
+// (ending at to) and its length, or nil if to can't be reached.
+func lanePath(room *Room, from, to string, openOnly bool) ([]string, int) {
+	return weightedLanePath(room, from, to, openOnly, currentLaneUnits)
+}
+
+// weightedLanePath is lanePath with each lane's length given by length.
+func weightedLanePath(room *Room, from, to string, openOnly bool, length func(l *Lane) int) ([]string, int) {
+	if room.Planets[from] == nil || room.Planets[to] == nil || from == to {
+		return nil, 0
+	}
+	dist := map[string]int{from: 0}
+	prev := map[string]string{}
+	done := map[string]bool{}
+	names := planetNames(room.Planets)
+	for {
+		cur, best := "", math.MaxInt
+		for _, n := range names {
+			if d, ok := dist[n]; ok && !done[n] && d < best {
+				cur, best = n, d
+			}
+		}
+		if cur == "" || cur == to {
+			break
+		}
+		done[cur] = true
+		for _, l := range laneEdges(room, cur, openOnly) {
+			n := l.other(cur)
+			d := length(l)
+			if old, ok := dist[n]; !done[n] && (!ok || best+d < old) {
+				dist[n] = best + d
+				prev[n] = cur
+			}
+		}
+	}
+	units, ok := dist[to]
+	if !ok {
+		return nil, 0
+	}
+	path := []string{}
+	for n := to; n != from; n = prev[n] {
+		path = append([]string{n}, path...)
+	}
+	return path, units
+}
+
+// laneGate returns a Gate Station at either end of the lane, if one operates.
+func laneGate(room *Room, l *Lane) *Facility {
+	for _, name := range []string{l.A, l.B} {
+		if pl := room.Planets[name]; pl != nil {
+			for _, f := range pl.Facilities {
+				if f != nil && f.Type == "Gate Station" {
+					return f
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// laneTollFor is what p pays to use a lane: the toll gate fee plus any Gate
+// Station toll. Owners pass their own gates for free.
+func laneTollFor(room *Room, p *Player, l *Lane) int {
+	gate := laneGate(room, l)
+	if gate != nil && gate.Owner == p.ID {
+		return 0
+	}
+	toll := 0
+	if l.Kind == laneToll {
+		toll = l.Toll
+	}
+	if gate != nil {
+		toll += gateStationToll
+	}
+	return toll
+}
+
+// payLaneTolls charges for every lane on a departing ship's path. Tolls on lanes
+// through a Gate Station accrue to its owner; the rest go to the Federation.
+func (gs *GameServer) payLaneTolls(room *Room, p *Player, ship *Ship) {
+	total := 0
+	at := ship.TransitFrom
+	for _, next := range ship.TransitPath {
+		l := findLane(room, at, next)
+		at = next
+		if l == nil {
+			continue
+		}
+		toll := laneTollFor(room, p, l)
+		if gate := laneGate(room, l); gate != nil && gate.Owner != "" && toll > 0 {
+			gate.AccruedMoney += toll
+		}
+		total += toll
+	}
+	if total <= 0 {
+		return
+	}
+	gs.logAction(room, p, fmt.Sprintf("Hyperlane tolls paid: $%d%s", total, shipSuffix(p, ship)))
+	gs.chargeWithCredit(room, p, total, "lane tolls", ship.TransitFrom)
+}
+
+// pathHazardous reports whether a ship's current trip crosses a hazardous lane.
+func pathHazardous(room *Room, ship *Ship) bool {
+	at := ship.TransitFrom
+	for _, next := range ship.TransitPath {
+		if l := findLane(room, at, next); l != nil && l.Kind == laneHazardous {
+			return true
+		}
+		at = next
+	}
+	return false
+}
+
+// isChokepoint reports whether removing planet would split the lane graph.
+func isChokepoint(room *Room, planet string) bool {
+	names := planetNames(room.Planets)
+	if len(room.Lanes) == 0 || len(names) < 3 {
+		return false
+	}
+	start := names[0]
+	if start == planet {
+		start = names[1]
+	}
+	seen := map[string]bool{planet: true, start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, l := range laneEdges(room, cur, false) {
+			if n := l.other(cur); !seen[n] {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return len(seen) < len(names)
+}
+
+func chokepoints(room *Room) []string {
+	out := []string{}
+	for _, n := range planetNames(room.Planets) {
+		if isChokepoint(room, n) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// laneClosureNews closes a random lane touching planet for the news item's duration.
+func laneClosureNews(room *Room, planet string, turns int) (NewsItem, bool) {
+	lanes := laneEdges(room, planet, true)
+	if len(room.Lanes) == 0 || len(lanes) == 0 {
+		return NewsItem{}, false
+	}
+	l := lanes[rand.Intn(len(lanes))]
+	causes := []string{"Ion storm", "Gravitational shear", "Federation blockade", "Debris field"}
+	headline := fmt.Sprintf("%s closes the %s-%s hyperlane", causes[rand.Intn(len(causes))], l.A, l.B)
+	return NewsItem{Headline: headline, Planet: planet, TurnsRemaining: turns, ClosedLane: l.ID}, true
+}
+
+// holdForClosedLanes keeps a ship in port when every lane route to its destination is
+// shut. The owner hears about it on the first turn held, not on every turn after.
+func (gs *GameServer) holdForClosedLanes(room *Room, p *Player, ship *Ship, notify bool) {
+	dest := ship.DestinationPlanet
+	if p.IsBot {
+		// Let the AI pick somewhere reachable
+		ship.DestinationPlanet = ""
+		ship.Waypoints = nil
+	}
+	if ship.LaneHeldFor != dest {
+		ship.LaneHeldFor = dest
+		if !p.IsBot {
+			gs.enqueueModal(p, "Hyperlanes Closed", fmt.Sprintf("Every hyperlane route from %s to %s is closed. %s waits in port until one reopens.", ship.CurrentPlanet, dest, capitalizeFirst(p.shipLabel(ship))))
+		}
+		gs.logAction(room, p, fmt.Sprintf("Held at %s: hyperlanes to %s closed%s", ship.CurrentPlanet, dest, shipSuffix(p, ship)))
+	}
+	gs.chargeDockTax(room, p, ship, notify)
+}
+
+// lanesPayload publishes the hyperlane graph, closures and chokepoints for rendering.
+func lanesPayload(room *Room) map[string]interface{} {
+	lanes := make([]map[string]interface{}, 0, len(room.Lanes))
+	for _, l := range room.Lanes {
+		entry := map[string]interface{}{
+			"id":     l.ID,
+			"a":      l.A,
+			"b":      l.B,
+			"kind":   l.Kind,
+			"units":  l.Units,
+			"closed": laneClosed(room, l),
+		}
+		if l.Toll > 0 {
+			entry["toll"] = l.Toll
+		}
+		if gate := laneGate(room, l); gate != nil {
+			entry["gateToll"] = gateStationToll
+		}
+		lanes = append(lanes, entry)
+	}
+	return map[string]interface{}{
+		"lanes":       lanes,
+		"chokepoints": chokepoints(room),
+	}
+}
@@ -0,0 +1,390 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Lot kinds for player-run auctions
+const (
+	lotFacility = "facility"
+	lotGoods    = "goods"
+	lotUpgrade  = "upgrade"
+)
+
+// Upgrade kinds that can be stripped from a flagship and auctioned
+const (
+	upgradeCapacity = "capacity"
+	upgradeSpeed    = "speed"
+	upgradeFuel     = "fuelCapacity"
+)
+
+const maxListingsPerPlayer = 3
+const maxListingTurns = 5
+
+// upgradeUnitValue is the shipyard price per unit of each upgrade, used for
+// suggested bids and to move UpgradeInvestment into escrow with the lot.
+func upgradeUnitValue(kind string) int {
+	switch kind {
+	case upgradeCapacity:
+		return 100
+	case upgradeSpeed:
+		return 1000
+	case upgradeFuel:
+		return 50
+	}
+	return 0
+}
+
+// lotLabel names an auction lot in player-facing text.
+func lotLabel(a *FederationAuction) string {
+	switch a.LotKind {
+	case lotGoods:
+		return fmt.Sprintf("%d %s", a.Quantity, a.Good)
+	case lotUpgrade:
+		switch a.UpgradeKind {
+		case upgradeSpeed:
+			return fmt.Sprintf("+%d engine speed", a.Quantity)
+		case upgradeFuel:
+			return fmt.Sprintf("+%d fuel tank capacity", a.Quantity)
+		}
+		return fmt.Sprintf("+%d cargo capacity", a.Quantity)
+	}
+	return a.FacilityType + " on " + a.Planet
+}
+
+// lotShip picks the winner's ship docked at the lot's planet with the most free
+// cargo for a goods lot. Returns nil when no docked ship can hold it.
+func lotShip(a *FederationAuction, p *Player) *Ship {
+	var best *Ship
+	bestFree := -1
+	for _, s := range p.ships() {
+		if s.InTransit || s.CurrentPlanet != a.Planet {
+			continue
+		}
+		free := s.cargoCapacity() - inventoryTotal(s.Inventory)
+		if free > bestFree {
+			best, bestFree = s, free
+		}
+	}
+	if bestFree < a.Quantity {
+		return nil
+	}
+	return best
+}
+
+func findAuction(room *Room, id string) *FederationAuction {
+	if room.ActiveAuction != nil && room.ActiveAuction.ID == id {
+		return room.ActiveAuction
+	}
+	for _, a := range room.Auctions {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func facilityListed(room *Room, facilityID string) bool {
+	for _, a := range room.Auctions {
+		if a.LotKind == lotFacility && a.FacilityID == facilityID {
+			return true
+		}
+	}
+	return false
+}
+
+// listingRequest is the client payload for listAuction.
+type listingRequest struct {
+	Kind         string `json:"kind"`
+	ShipID       string `json:"shipId"`
+	Good         string `json:"good"`
+	Quantity     int    `json:"quantity"`
+	Upgrade      string `json:"upgrade"`
+	FacilityID   string `json:"facilityId"`
+	ReservePrice int    `json:"reservePrice"`
+	Turns        int    `json:"turns"`
+	Format       string `json:"format"`
+}
+
+// handleListAuction puts a player's lot up for auction. The lot is taken into
+// escrow immediately so it can't be spent or sold twice while bidding runs.
+func (gs *GameServer) handleListAuction(room *Room, p *Player, req listingRequest) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	open := 0
+	for _, a := range room.Auctions {
+		if a.Seller == p.ID {
+			open++
+		}
+	}
+	if open >= maxListingsPerPlayer {
+		gs.enqueueModal(p, "Too Many Listings", fmt.Sprintf("You can run at most %d auctions at a time.", maxListingsPerPlayer))
+		return
+	}
+	format := req.Format
+	if !containsGood(auctionFormats, format) {
+		format = auctionEnglish
+	}
+	turns := clampInt(req.Turns, 1, maxListingTurns)
+	a := &FederationAuction{
+		ID:         "lot_" + randID(),
+		Seller:     p.ID,
+		SellerName: p.Name,
+		LotKind:    req.Kind,
+		Bids:       make(map[PlayerID]int),
+	}
+	switch req.Kind {
+	case lotGoods:
+		ship := p.shipByID(req.ShipID)
+		if ship == nil || req.Quantity <= 0 || ship.Inventory[req.Good] < req.Quantity {
+			gs.enqueueModal(p, "Invalid Listing", "You don't have that much "+defaultStr(req.Good, "cargo")+" aboard.")
+			return
+		}
+		if ship.InTransit {
+			gs.enqueueModal(p, "In Transit", "Cargo can only be put up for auction from a docked ship.")
+			return
+		}
+		a.Good, a.Quantity, a.ShipID = req.Good, req.Quantity, ship.ID
+		a.LotAvgCost = ship.InventoryAvgCost[req.Good]
+		a.Planet = ship.CurrentPlanet
+		a.SuggestedBid = req.Quantity * maxInt(1, a.LotAvgCost)
+		ship.Inventory[req.Good] -= req.Quantity
+		if ship.Inventory[req.Good] == 0 {
+			delete(ship.Inventory, req.Good)
+			delete(ship.InventoryAvgCost, req.Good)
+		}
+	case lotUpgrade:
+		if reason := stripUpgrade(p, req.Upgrade, req.Quantity); reason != "" {
+			gs.enqueueModal(p, "Invalid Listing", reason)
+			return
+		}
+		a.UpgradeKind, a.Quantity = req.Upgrade, req.Quantity
+		a.Planet = p.CurrentPlanet
+		a.SuggestedBid = req.Quantity * upgradeUnitValue(req.Upgrade)
+		a.LotBook = minInt(p.UpgradeInvestment, a.SuggestedBid)
+		p.UpgradeInvestment -= a.LotBook
+	case lotFacility:
+		planet, f := findFacility(room, req.FacilityID)
+		if f == nil || f.Owner != p.ID {
+			gs.enqueueModal(p, "Invalid Listing", "You can only auction facilities you own.")
+			return
+		}
+		if facilityListed(room, f.ID) {
+			gs.enqueueModal(p, "Invalid Listing", "That facility is already up for auction.")
+			return
+		}
+		a.FacilityID, a.FacilityType, a.Planet, a.UsageCharge = f.ID, f.Type, planet.Name, f.UsageCharge
+		a.SuggestedBid = f.PurchasePrice
+	default:
+		return
+	}
+	a.SuggestedBid = maxInt(a.SuggestedBid, req.ReservePrice)
+	setupAuctionFormat(a, format)
+	a.ReservePrice = maxInt(1, req.ReservePrice)
+	a.TurnsLeft = turns
+	if format == auctionDutch {
+		a.CurrentPrice = maxInt(a.CurrentPrice, a.ReservePrice*2)
+		a.MinIncrement = maxInt(1, (a.CurrentPrice-a.ReservePrice)/maxInt(1, turns-1))
+	}
+	room.Auctions = append(room.Auctions, a)
+	gs.placeBotBids(room, a)
+	gs.logAction(room, p, fmt.Sprintf("Listed %s for %s auction (reserve $%d, %d turns)", lotLabel(a), auctionFormatLabel(format), a.ReservePrice, turns))
+	gs.logGeneral(room, fmt.Sprintf("%s is auctioning %s (%s, reserve $%d)", p.Name, lotLabel(a), auctionFormatLabel(format), a.ReservePrice))
+}
+
+// stripUpgrade removes upgrade units from the flagship for escrow.
+func stripUpgrade(p *Player, kind string, units int) string {
+	if units <= 0 {
+		return "Choose how many upgrade units to auction."
+	}
+	switch kind {
+	case upgradeCapacity:
+		if p.CapacityBonus < units {
+			return "Your flagship doesn't have that much extra cargo capacity."
+		}
+		if inventoryTotal(p.Inventory) > p.cargoCapacity()-units {
+			return "Unload cargo before removing hold space."
+		}
+		p.CapacityBonus -= units
+	case upgradeSpeed:
+		if p.SpeedBonus < units {
+			return "Your flagship doesn't have that much extra engine speed."
+		}
+		p.SpeedBonus -= units
+	case upgradeFuel:
+		if p.FuelCapacityBonus < units {
+			return "Your flagship doesn't have that much extra fuel capacity."
+		}
+		p.FuelCapacityBonus -= units
+		p.Fuel = minInt(p.Fuel, p.maxFuel())
+	default:
+		return "Unknown upgrade."
+	}
+	return ""
+}
+
+func applyUpgrade(s *Ship, kind string, units int) {
+	switch kind {
+	case upgradeCapacity:
+		s.CapacityBonus += units
+	case upgradeSpeed:
+		s.SpeedBonus += units
+	case upgradeFuel:
+		s.FuelCapacityBonus += units
+	}
+}
+
+// handleCancelAuction withdraws a listing that hasn't drawn any bids.
+func (gs *GameServer) handleCancelAuction(room *Room, p *Player, auctionID string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	a := findAuction(room, auctionID)
+	if a == nil || a.Seller != p.ID {
+		return
+	}
+	if len(a.Bids) > 0 {
+		gs.enqueueModal(p, "Bids Received", "You can't withdraw a lot once bidding has started.")
+		return
+	}
+	gs.closeListing(room, a, nil, 0)
+}
+
+// processPlayerAuctions advances and settles player listings. Runs alongside the
+// Federation auction in handleFederationAuctions.
+func (gs *GameServer) processPlayerAuctions(room *Room) {
+	open := append([]*FederationAuction(nil), room.Auctions...)
+	for _, a := range open {
+		settled := gs.advanceAuction(room, a)
+		a.TurnsLeft--
+		if settled || a.TurnsLeft <= 0 {
+			gs.settleListing(room, a)
+		}
+	}
+}
+
+// settleListing picks a winner (falling back past anyone who can't pay) and closes the lot.
+func (gs *GameServer) settleListing(room *Room, a *FederationAuction) {
+	releaseAllBids(room, a)
+	winner, price, _ := gs.pickAuctionWinner(room, a)
+	gs.closeListing(room, a, winner, price)
+}
+
+// closeListing removes a listing and transfers the lot and proceeds together:
+// to the winner if there is one, otherwise the lot goes back to the seller.
+func (gs *GameServer) closeListing(room *Room, a *FederationAuction, winner *Player, price int) {
+	releaseAllBids(room, a)
+	kept := room.Auctions[:0]
+	for _, x := range room.Auctions {
+		if x != a {
+			kept = append(kept, x)
+		}
+	}
+	room.Auctions = kept
+	seller := room.Players[a.Seller]
+
+	if winner != nil && a.LotKind == lotFacility {
+		planet, f := findFacility(room, a.FacilityID)
+		if f == nil || f.Owner != a.Seller || seller == nil {
+			// The facility changed hands or the seller left; nothing to deliver
+			winner = nil
+		} else {
+			gs.completeFacilitySale(room, planet, f, seller, winner, price)
+			return
+		}
+	}
+	if winner == nil {
+		gs.returnLot(room, a)
+		if seller != nil && !seller.IsBot {
+			gs.enqueueModal(seller, "Auction Closed", fmt.Sprintf("Your auction for %s ended without a sale. The lot has been returned to you.", lotLabel(a)))
+		}
+		return
+	}
+
+	winner.Money -= price
+	gs.creditPlayer(room, a.Seller, price)
+	cost := 0
+	if a.LotKind == lotGoods {
+		cost = a.Quantity * a.LotAvgCost
+	}
+	recordSaleFor(room, a.Seller, price, cost)
+	switch a.LotKind {
+	case lotGoods:
+		ship := lotShip(a, winner)
+		oldQty := ship.Inventory[a.Good]
+		newQty := oldQty + a.Quantity
+		ship.Inventory[a.Good] = newQty
+		ship.InventoryAvgCost[a.Good] = (oldQty*ship.InventoryAvgCost[a.Good] + price) / newQty
+	case lotUpgrade:
+		applyUpgrade(&winner.Ship, a.UpgradeKind, a.Quantity)
+		winner.UpgradeInvestment += price
+		recordDeduction(winner, deductUpgrades, price)
+	}
+	gs.logAction(room, winner, fmt.Sprintf("Won auction for %s from %s at $%d", lotLabel(a), a.SellerName, price))
+	if !winner.IsBot {
+		gs.enqueueModal(winner, "Auction Won!", fmt.Sprintf("You won %s from %s for %d credits.", lotLabel(a), a.SellerName, price))
+	}
+	if seller != nil {
+		gs.logAction(room, seller, fmt.Sprintf("Auctioned %s to %s for $%d", lotLabel(a), winner.Name, price))
+		if !seller.IsBot {
+			gs.enqueueModal(seller, "Lot Sold", fmt.Sprintf("%s bought your %s for %d credits.", winner.Name, lotLabel(a), price))
+		}
+	}
+	gs.logGeneral(room, fmt.Sprintf("%s won %s's auction for %s at $%d", winner.Name, a.SellerName, lotLabel(a), price))
+}
+
+// returnLot hands escrowed goods or upgrades back to the seller, whether they're
+// still in the room or only persisted. Facilities never left the seller.
+func (gs *GameServer) returnLot(room *Room, a *FederationAuction) {
+	if seller := room.Players[a.Seller]; seller != nil {
+		switch a.LotKind {
+		case lotGoods:
+			ship := seller.shipByID(a.ShipID)
+			if ship == nil {
+				ship = &seller.Ship
+			}
+			oldQty := ship.Inventory[a.Good]
+			newQty := oldQty + a.Quantity
+			ship.Inventory[a.Good] = newQty
+			ship.InventoryAvgCost[a.Good] = (oldQty*ship.InventoryAvgCost[a.Good] + a.Quantity*a.LotAvgCost) / newQty
+		case lotUpgrade:
+			applyUpgrade(&seller.Ship, a.UpgradeKind, a.Quantity)
+			seller.UpgradeInvestment += a.LotBook
+		}
+		return
+	}
+	snap := room.Persist[a.Seller]
+	if snap == nil {
+		return
+	}
+	switch a.LotKind {
+	case lotGoods:
+		if snap.Inventory == nil {
+			snap.Inventory = map[string]int{}
+			snap.InventoryAvgCost = map[string]int{}
+		}
+		snap.Inventory[a.Good] += a.Quantity
+		snap.InventoryAvgCost[a.Good] = a.LotAvgCost
+	case lotUpgrade:
+		switch a.UpgradeKind {
+		case upgradeCapacity:
+			snap.CapacityBonus += a.Quantity
+		case upgradeSpeed:
+			snap.SpeedBonus += a.Quantity
+		case upgradeFuel:
+			snap.FuelCapacityBonus += a.Quantity
+		}
+		snap.UpgradeInvestment += a.LotBook
+	}
+}
+
+// auctionsPayload lists open player auctions, soonest-closing first.
+func auctionsPayload(room *Room, viewer *Player) []map[string]interface{} {
+	list := append([]*FederationAuction(nil), room.Auctions...)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].TurnsLeft < list[j].TurnsLeft })
+	out := make([]map[string]interface{}, 0, len(list))
+	for _, a := range list {
+		out = append(out, auctionPayload(room, a, viewer))
+	}
+	return out
+}
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Mission kinds
+const (
+	missionDelivery = "delivery" // bring goods to the destination
+	missionCourier  = "courier"  // carry passengers to the destination; they occupy berths until delivered
+	missionFuel     = "fuel"     // bring spare fuel to a stranded outpost
+)
+
+const missionBoardSize = 3      // offers posted per planet at most
+const missionOfferTurns = 6     // turns an unaccepted offer stays on the board
+const maxActiveMissions = 3     // contracts a player may hold at once
+const missionPostChancePct = 35 // per planet per turn
+
+// Mission is a contract posted on a planet's board. Once accepted it moves to
+// the player and is bound to the ship that took it.
+type Mission struct {
+	ID           string `json:"id"`
+	Kind         string `json:"kind"`
+	Origin       string `json:"origin"`
+	Destination  string `json:"destination"`
+	Good         string `json:"good,omitempty"`
+	Quantity     int    `json:"quantity"` // goods units, passengers or fuel units
+	Reward       int    `json:"reward"`
+	Penalty      int    `json:"penalty"`
+	Deadline     int    `json:"deadline"`
+	OfferExpires int    `json:"offerExpires,omitempty"`
+	ShipID       string `json:"shipId,omitempty"`
+}
+
+func cloneMissions(in []*Mission) []*Mission {
+	if in == nil {
+		return nil
+	}
+	out := make([]*Mission, 0, len(in))
+	for _, m := range in {
+		if m != nil {
+			c := *m
+			out = append(out, &c)
+		}
+	}
+	return out
+}
+
+// describe is the one-line summary used in modals and logs.
+func (m *Mission) describe() string {
+	switch m.Kind {
+	case missionCourier:
+		return fmt.Sprintf("carry %d passengers from %s to %s", m.Quantity, m.Origin, m.Destination)
+	case missionFuel:
+		return fmt.Sprintf("deliver %d units of emergency fuel to %s", m.Quantity, m.Destination)
+	}
+	return fmt.Sprintf("deliver %d %s to %s", m.Quantity, m.Good, m.Destination)
+}
+
+// missionTravelTurns is how many turns a base-speed ship needs between two planets.
+func missionTravelTurns(room *Room, from, to string) int {
+	d := distanceUnits(room, from, to)
+	return maxInt(1, (d+baseShipSpeed-1)/baseShipSpeed)
+}
+
+// refreshMissionBoards drops stale offers and posts new ones. Called once per turn.
+func (gs *GameServer) refreshMissionBoards(room *Room) {
+	if room.MissionBoard == nil {
+		room.MissionBoard = map[string][]*Mission{}
+	}
+	names := planetNames(room.Planets)
+	if len(names) < 2 {
+		return
+	}
+	for _, origin := range names {
+		offers := room.MissionBoard[origin][:0]
+		for _, m := range room.MissionBoard[origin] {
+			if room.Turn < m.OfferExpires {
+				offers = append(offers, m)
+			}
+		}
+		room.MissionBoard[origin] = offers
+		if len(offers) >= missionBoardSize || rand.Intn(100) >= missionPostChancePct {
+			continue
+		}
+		dest := names[rand.Intn(len(names))]
+		if dest == origin {
+			continue
+		}
+		if m := generateMission(room, origin, dest); m != nil {
+			room.MissionBoard[origin] = append(room.MissionBoard[origin], m)
+		}
+	}
+}
+
+func generateMission(room *Room, origin, dest string) *Mission {
+	dist := distanceUnits(room, origin, dest)
+	m := &Mission{
+		ID:           "mission_" + randID(),
+		Origin:       origin,
+		Destination:  dest,
+		Deadline:     room.Turn + missionTravelTurns(room, origin, dest)*2 + 3,
+		OfferExpires: room.Turn + missionOfferTurns,
+	}
+	switch rand.Intn(3) {
+	case 0:
+		// Goods the destination pays well for, sourced anywhere
+		destPlanet := room.Planets[dest]
+		goods := make([]string, 0, len(destPlanet.Prices))
+		for g := range destPlanet.Prices {
+			goods = append(goods, g)
+		}
+		if len(goods) == 0 {
+			return nil
+		}
+		m.Kind = missionDelivery
+		m.Good = goods[rand.Intn(len(goods))]
+		m.Quantity = 10 + rand.Intn(31) // 10-40
+		// Pays a premium over selling the goods at the destination, since they're surrendered
+		m.Reward = m.Quantity*destPlanet.Prices[m.Good]*5/4 + dist*5
+	case 1:
+		m.Kind = missionCourier
+		m.Quantity = 2 + rand.Intn(7) // 2-8 passengers
+		m.Reward = dist*12 + m.Quantity*40
+	default:
+		m.Kind = missionFuel
+		m.Quantity = 20 + rand.Intn(41) // 20-60 units
+		fp := room.Planets[origin].FuelPrice
+		m.Reward = m.Quantity*maxInt(fp, 5)*3 + dist*3
+		// Emergencies are urgent
+		m.Deadline = room.Turn + missionTravelTurns(room, origin, dest) + 2
+	}
+	m.Penalty = m.Reward / 2
+	return m
+}
+
+// handleAcceptMission takes a contract from the board at the ship's planet.
+func (gs *GameServer) handleAcceptMission(room *Room, p *Player, missionID, shipID string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	ship := p.shipByID(shipID)
+	if ship == nil || ship.Route != nil {
+		return
+	}
+	if ship.InTransit {
+		gs.enqueueModal(p, "In Transit", "Contracts can only be signed while docked.")
+		return
+	}
+	if len(p.Missions) >= maxActiveMissions {
+		gs.enqueueModal(p, "Too Many Contracts", fmt.Sprintf("You can hold at most %d contracts at once.", maxActiveMissions))
+		return
+	}
+	board := room.MissionBoard[ship.CurrentPlanet]
+	idx := -1
+	for i, m := range board {
+		if m.ID == missionID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		gs.enqueueModal(p, "Contract Unavailable", "That contract is no longer posted at "+ship.CurrentPlanet+".")
+		return
+	}
+	m := board[idx]
+	if reputationAt(room, p, m.Origin) < reputationMissionMinLevel {
+		gs.enqueueModal(p, "Contract Refused", "The "+planetFaction(room, m.Origin)+" won't trust you with contracts until your standing improves.")
+		return
+	}
+	if free := ship.berths() - berthsUsed(p, ship); m.Kind == missionCourier && m.Quantity > free {
+		gs.enqueueModal(p, "No Berths", fmt.Sprintf("This contract needs %d berths but only %d are free aboard %s.", m.Quantity, maxInt(0, free), p.shipLabel(ship)))
+		return
+	}
+	room.MissionBoard[ship.CurrentPlanet] = append(board[:idx:idx], board[idx+1:]...)
+	m.ShipID = ship.ID
+	m.OfferExpires = 0
+	p.Missions = append(p.Missions, m)
+	gs.logAction(room, p, fmt.Sprintf("Accepted contract: %s by turn %d for $%d%s", m.describe(), m.Deadline, m.Reward, shipSuffix(p, ship)))
+}
+
+// handleAbandonMission drops a contract and pays its penalty.
+func (gs *GameServer) handleAbandonMission(room *Room, p *Player, missionID string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	for i, m := range p.Missions {
+		if m.ID != missionID {
+			continue
+		}
+		p.Missions = append(p.Missions[:i:i], p.Missions[i+1:]...)
+		gs.logAction(room, p, fmt.Sprintf("Abandoned contract to %s: $%d penalty", m.describe(), m.Penalty))
+		gs.adjustReputation(room, p, planetFaction(room, m.Origin), -reputationMissionLoss, "abandoned a contract")
+		gs.chargeWithCredit(room, p, m.Penalty, "contract penalties", p.CurrentPlanet)
+		return
+	}
+}
+
+// completeMissions settles contracts for a ship that just arrived at a planet.
+func (gs *GameServer) completeMissions(room *Room, p *Player, ship *Ship) {
+	kept := p.Missions[:0]
+	for _, m := range p.Missions {
+		if m.ShipID != ship.ID || m.Destination != ship.CurrentPlanet {
+			kept = append(kept, m)
+			continue
+		}
+		if room.Turn > m.Deadline {
+			gs.failMission(room, p, m)
+			continue
+		}
+		basis := 0
+		if m.Kind == missionDelivery {
+			basis = m.Quantity * ship.InventoryAvgCost[m.Good]
+		}
+		if !gs.fulfilMission(room, ship, m) {
+			kept = append(kept, m)
+			if !p.IsBot {
+				gs.enqueueModal(p, "Contract Incomplete", "You arrived at "+m.Destination+" without what's needed to "+m.describe()+".")
+			}
+			continue
+		}
+		p.Money += m.Reward
+		if m.Kind == missionDelivery {
+			// The contract takes the cargo, so it's booked as a sale for tax
+			recordSale(p, m.Reward, basis)
+		}
+		gs.logAction(room, p, fmt.Sprintf("Contract complete: %s (+$%d)%s", m.describe(), m.Reward, shipSuffix(p, ship)))
+		gs.adjustReputation(room, p, planetFaction(room, m.Origin), reputationMissionGain, "completed a contract")
+		if !p.IsBot {
+			gs.enqueueModal(p, "Contract Complete", fmt.Sprintf("You completed the contract to %s and were paid %d credits.", m.describe(), m.Reward))
+		}
+	}
+	p.Missions = kept
+}
+
+// fulfilMission hands over the cargo, passengers or fuel. Returns false if the ship lacks it.
+func (gs *GameServer) fulfilMission(room *Room, ship *Ship, m *Mission) bool {
+	switch m.Kind {
+	case missionDelivery:
+		if ship.Inventory[m.Good] < m.Quantity {
+			return false
+		}
+		ship.Inventory[m.Good] -= m.Quantity
+		if ship.Inventory[m.Good] == 0 {
+			delete(ship.Inventory, m.Good)
+			delete(ship.InventoryAvgCost, m.Good)
+		}
+		if pl := room.Planets[m.Destination]; pl != nil {
+			pl.Goods[m.Good] += m.Quantity
+		}
+	case missionFuel:
+		if ship.Fuel < m.Quantity {
+			return false
+		}
+		ship.Fuel -= m.Quantity
+	}
+	return true
+}
+
+// expireMissions fails any contract whose deadline has passed. Runs in the travel step.
+func (gs *GameServer) expireMissions(room *Room) {
+	for _, p := range room.Players {
+		if p.Bankrupt || len(p.Missions) == 0 {
+			continue
+		}
+		kept := p.Missions[:0]
+		for _, m := range p.Missions {
+			if room.Turn > m.Deadline {
+				gs.failMission(room, p, m)
+				continue
+			}
+			kept = append(kept, m)
+		}
+		p.Missions = kept
+	}
+}
+
+func (gs *GameServer) failMission(room *Room, p *Player, m *Mission) {
+	gs.logAction(room, p, fmt.Sprintf("Contract failed: %s ($%d penalty)", m.describe(), m.Penalty))
+	if !p.IsBot {
+		gs.enqueueModal(p, "Contract Failed", fmt.Sprintf("You missed the deadline to %s. A %d-credit penalty has been charged.", m.describe(), m.Penalty))
+	}
+	gs.adjustReputation(room, p, planetFaction(room, m.Origin), -reputationMissionLoss, "failed a contract")
+	gs.chargeWithCredit(room, p, m.Penalty, "contract penalties", p.CurrentPlanet)
+}
+
+// missionBoardPayload lists open offers by planet.
+func missionBoardPayload(room *Room) map[string][]*Mission {
+	out := make(map[string][]*Mission, len(room.MissionBoard))
+	for planet, offers := range room.MissionBoard {
+		if len(offers) > 0 {
+			out[planet] = cloneMissions(offers)
+		}
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"fmt"
+	"math"
+)
+
+// Route planning modes
+const (
+	routeCheapest = "cheapest" // least spent on fuel and dock tax
+	routeFastest  = "fastest"  // fewest turns in transit
+)
+
+const waypointStopFee = 10 // dock tax assumed per intermediate stop when costing a route
+
+func fuelPriceAt(room *Room, planet string) int {
+	if pl := room.Planets[planet]; pl != nil && pl.FuelPrice > 0 {
+		return pl.FuelPrice
+	}
+	return 10
+}
+
+// RoutePlan is a multi-hop path from a ship's planet, refuelling at each stop.
+type RoutePlan struct {
+	Waypoints []string // stops after the origin, ending at the destination
+	Distance  int
+	Turns     int
+	FuelCost  int
+	Tolls     int
+}
+
+// planRoute runs Dijkstra over the open hyperlanes. Every lane must fit in a full
+// tank; the ship tops up at each stop. Cheapest weighs fuel bought at the departure
+// planet's price, tolls and dock tax at stops; fastest weighs turns in transit.
+func planRoute(room *Room, p *Player, ship *Ship, from, to, mode string) *RoutePlan {
+	names := planetNames(room.Planets)
+	if room.Planets[from] == nil || room.Planets[to] == nil || from == to {
+		return nil
+	}
+	speed := maxInt(1, ship.speed())
+	// Legs are measured as departures from this turn will find them
+	length := departureLaneUnits(room)
+	dist := map[string]int{from: 0}
+	prev := map[string]string{}
+	done := map[string]bool{}
+	for {
+		cur, best := "", math.MaxInt
+		for _, n := range names {
+			if d, ok := dist[n]; ok && !done[n] && d < best {
+				cur, best = n, d
+			}
+		}
+		if cur == "" || cur == to {
+			break
+		}
+		done[cur] = true
+		for _, l := range laneEdges(room, cur, true) {
+			n, d := l.other(cur), length(l)
+			if done[n] || d > ship.maxFuel() {
+				continue
+			}
+			w := d*fuelPriceAt(room, cur) + laneTollFor(room, p, l) + waypointStopFee
+			if mode == routeFastest {
+				// Turns dominate; distance breaks ties
+				w = ((d+speed-1)/speed)*10000 + d
+			}
+			if old, ok := dist[n]; !ok || best+w < old {
+				dist[n] = best + w
+				prev[n] = cur
+			}
+		}
+	}
+	if _, ok := dist[to]; !ok {
+		return nil
+	}
+	plan := &RoutePlan{}
+	for n := to; n != from; n = prev[n] {
+		plan.Waypoints = append([]string{n}, plan.Waypoints...)
+	}
+	at := from
+	for _, n := range plan.Waypoints {
+		d := departureUnits(room, at, n)
+		if l := findLane(room, at, n); l != nil {
+			d = length(l)
+			plan.Tolls += laneTollFor(room, p, l)
+		}
+		plan.Distance += d
+		plan.Turns += (d + speed - 1) / speed
+		plan.FuelCost += d * fuelPriceAt(room, at)
+		at = n
+	}
+	return plan
+}
+
+// planRouteFor answers a planRoute request and, when apply is set, queues the
+// waypoints and sets off. Callers hold the room lock.
+func (gs *GameServer) planRouteFor(room *Room, p *Player, shipID, dest, mode string, apply bool) map[string]interface{} {
+	ship := p.shipByID(shipID)
+	if ship == nil {
+		return nil
+	}
+	if mode != routeFastest {
+		mode = routeCheapest
+	}
+	out := map[string]interface{}{"shipId": ship.ID, "destination": dest, "mode": mode}
+	from := ship.CurrentPlanet
+	if ship.InTransit {
+		// Plan onward from where the ship is heading
+		from = ship.DestinationPlanet
+	}
+	plan := planRoute(room, p, ship, from, dest, mode)
+	if plan == nil {
+		out["error"] = "No open hyperlane route to " + dest + " fits within your fuel tank."
+		return out
+	}
+	out["from"] = from
+	out["waypoints"] = plan.Waypoints
+	out["distance"] = plan.Distance
+	out["turns"] = plan.Turns
+	out["fuelCost"] = plan.FuelCost
+	out["tolls"] = plan.Tolls
+	if !apply {
+		return out
+	}
+	if ship.Route != nil {
+		out["error"] = "The " + ship.Name + " is running an automated route. Dismiss the captain to steer it yourself."
+		return out
+	}
+	if ship.InTransit {
+		// Keep the current leg; queue everything after it
+		ship.Waypoints = append([]string(nil), plan.Waypoints...)
+		out["applied"] = true
+		gs.logAction(room, p, fmt.Sprintf("Queued route to %s via %d stops%s", dest, len(plan.Waypoints)-1, shipSuffix(p, ship)))
+		return out
+	}
+	ship.Waypoints = append([]string(nil), plan.Waypoints...)
+	if c := playerConvoy(room, p); c != nil && p.isFlagship(ship) && ship.Waypoints[0] != c.Destination {
+		gs.leaveConvoy(room, p, "changed course")
+	}
+	out["applied"] = gs.continueWaypoints(room, p, ship)
+	gs.logAction(room, p, fmt.Sprintf("Planned %s route to %s: %d units over %d turns%s", mode, dest, plan.Distance, plan.Turns, shipSuffix(p, ship)))
+	return out
+}
+
+// continueWaypoints sends a docked ship to its next queued waypoint, buying just
+// enough fuel for the leg. Returns false (and clears the queue) if stranded.
+func (gs *GameServer) continueWaypoints(room *Room, p *Player, ship *Ship) bool {
+	if len(ship.Waypoints) == 0 || ship.InTransit {
+		return false
+	}
+	next := ship.Waypoints[0]
+	ship.Waypoints = ship.Waypoints[1:]
+	if len(ship.Waypoints) == 0 {
+		ship.Waypoints = nil
+	}
+	need := departureUnits(room, ship.CurrentPlanet, next)
+	if short := need - ship.Fuel; short > 0 {
+		fp := fuelPriceAt(room, ship.CurrentPlanet)
+		units := minInt(minInt(short, ship.maxFuel()-ship.Fuel), maxInt(0, spendableFunds(p))/fp)
+		if units > 0 {
+			cost := units * fp
+			p.Money -= cost
+			ship.Fuel += units
+			gs.logAction(room, p, fmt.Sprintf("Auto-refueled %d units for $%d at %s%s", units, cost, ship.CurrentPlanet, shipSuffix(p, ship)))
+		}
+	}
+	if ship.Fuel < need {
+		ship.Waypoints = nil
+		gs.logAction(room, p, fmt.Sprintf("Route halted at %s: can't afford fuel for %s%s", ship.CurrentPlanet, next, shipSuffix(p, ship)))
+		if !p.IsBot {
+			gs.enqueueModal(p, "Route Halted", fmt.Sprintf("%s couldn't afford the fuel to continue from %s to %s. The remaining waypoints were cleared.", capitalizeFirst(p.shipLabel(ship)), ship.CurrentPlanet, next))
+		}
+		return false
+	}
+	ship.DestinationPlanet = next
+	return true
+}
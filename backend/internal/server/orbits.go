@@ -0,0 +1,147 @@
+package server
+
+import (
+	"math"
+	"math/rand"
+)
+
+const orbitCenter = 0.5
+const orbitMaxRadius = 0.45  // keeps every body inside the unit square
+const orbitMinRadius = 0.04  // bodies placed at the centre still drift
+const orbitBasePeriod = 40.0 // turns for one revolution at orbitBaseRadius
+const orbitBaseRadius = 0.3  // periods scale with radius^1.5, so inner bodies lap outer ones
+const orbitMinPeriod = 12    // fastest allowed revolution
+const orbitForecastTurns = 5 // upcoming positions published for planning
+
+// Orbit describes a body's circular path around the map's centre.
+type Orbit struct {
+	Radius float64 `json:"radius"`
+	Phase  float64 `json:"phase"`  // angle in radians at turn 0
+	Period float64 `json:"period"` // turns per revolution
+}
+
+// generateOrbits derives an orbit for each body from its starting position.
+func generateOrbits(rng *rand.Rand, pos map[string][2]float64) map[string]Orbit {
+	out := make(map[string]Orbit, len(pos))
+	for _, name := range sortedPositionNames(pos) {
+		p := pos[name]
+		dx, dy := p[0]-orbitCenter, p[1]-orbitCenter
+		r := math.Hypot(dx, dy)
+		if r < orbitMinRadius {
+			r = orbitMinRadius + rng.Float64()*orbitMinRadius
+		}
+		r = math.Min(r, orbitMaxRadius)
+		period := math.Max(orbitMinPeriod, orbitBasePeriod*math.Pow(r/orbitBaseRadius, 1.5))
+		out[name] = Orbit{Radius: r, Phase: math.Atan2(dy, dx), Period: period}
+	}
+	return out
+}
+
+func sortedPositionNames(pos map[string][2]float64) []string {
+	names := make([]string, 0, len(pos))
+	for n := range pos {
+		names = append(names, n)
+	}
+	return uniqueSorted(names)
+}
+
+func (o Orbit) positionAt(turn int) [2]float64 {
+	a := o.Phase + 2*math.Pi*float64(turn)/o.Period
+	return [2]float64{orbitCenter + o.Radius*math.Cos(a), orbitCenter + o.Radius*math.Sin(a)}
+}
+
+// positionsAt predicts every body's position on a given turn. Rooms without orbits
+// keep their fixed positions.
+func positionsAt(room *Room, turn int) map[string][2]float64 {
+	if len(room.Orbits) == 0 {
+		return room.PlanetPositions
+	}
+	out := make(map[string][2]float64, len(room.Orbits))
+	for name, o := range room.Orbits {
+		out[name] = o.positionAt(turn)
+	}
+	return out
+}
+
+// advanceOrbits moves every body to its position for the current turn and
+// re-measures the lanes. Ships already in transit keep the distance they
+// departed with, so only new departures see the changed geometry.
+func advanceOrbits(room *Room) {
+	if len(room.Orbits) == 0 {
+		return
+	}
+	room.PlanetPositions = positionsAt(room, room.Turn)
+	measureLanes(room)
+}
+
+// departureUnits is the distance a ship setting course now will be charged. Ships
+// leave on the next turn, after the orbits move, so the trip is measured at those
+// positions without disturbing the room's current geometry.
+func departureUnits(room *Room, from, to string) int {
+	if len(room.Orbits) == 0 {
+		return distanceUnits(room, from, to)
+	}
+	if from == "" || to == "" || from == to {
+		return 0
+	}
+	length := departureLaneUnits(room)
+	if len(room.Lanes) > 0 {
+		if path, units := weightedLanePath(room, from, to, true, length); path != nil {
+			return units
+		}
+		if path, units := weightedLanePath(room, from, to, false, length); path != nil {
+			return units
+		}
+	}
+	return length(&Lane{A: from, B: to, Units: 5})
+}
+
+// departureLaneUnits measures lanes as a ship setting course now will find them,
+// at next turn's orbital positions.
+func departureLaneUnits(room *Room) func(l *Lane) int {
+	if len(room.Orbits) == 0 {
+		return currentLaneUnits
+	}
+	pos := positionsAt(room, room.Turn+1)
+	scale := room.unitScale()
+	return func(l *Lane) int { return laneUnitsAt(pos, l, scale) }
+}
+
+func currentLaneUnits(l *Lane) int { return l.Units }
+
+// laneUnitsAt measures a lane between the given positions, keeping its current
+// length if either end is unplaced.
+func laneUnitsAt(pos map[string][2]float64, l *Lane, scale float64) int {
+	a, okA := pos[l.A]
+	b, okB := pos[l.B]
+	if !okA || !okB {
+		return l.Units
+	}
+	return positionUnits(a, b, scale)
+}
+
+func measureLanes(room *Room) {
+	for _, l := range room.Lanes {
+		l.Units = laneUnitsAt(room.PlanetPositions, l, room.unitScale())
+	}
+}
+
+// orbitsPayload publishes orbital parameters and the next few turns of positions
+// so clients can plan around launch windows.
+func orbitsPayload(room *Room) map[string]interface{} {
+	if len(room.Orbits) == 0 {
+		return nil
+	}
+	forecast := make([]map[string]interface{}, 0, orbitForecastTurns)
+	for t := room.Turn + 1; t <= room.Turn+orbitForecastTurns; t++ {
+		positions := map[string]map[string]float64{}
+		for name, p := range positionsAt(room, t) {
+			positions[name] = map[string]float64{"x": p[0], "y": p[1]}
+		}
+		forecast = append(forecast, map[string]interface{}{"turn": t, "positions": positions})
+	}
+	return map[string]interface{}{
+		"orbits":   room.Orbits,
+		"forecast": forecast,
+	}
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const baseBerths = 12              // passenger berths per hull, separate from cargo slots
+const passengerGroupsPerPlanet = 4 // waiting groups a planet holds at most
+const passengerWaitTurns = 5       // turns a group waits before finding other passage
+const passengerProdPerGroup = 12   // units of production per turn that bring in another group
+const fareBase = 20                // per passenger
+const farePerUnit = 2              // per passenger per distance unit
+const lateFarePenaltyPct = 15      // fare lost per turn late
+const minFarePct = 25              // passengers always pay at least this much
+const pirateRaidChance = 60        // 1-in-n per ship in transit with passengers
+
+// PassengerGroup is a party travelling together; they board and leave as one.
+type PassengerGroup struct {
+	ID            string `json:"id"`
+	Origin        string `json:"origin"`
+	Destination   string `json:"destination"`
+	Count         int    `json:"count"`
+	Fare          int    `json:"fare"` // total for the group
+	BoardedTurn   int    `json:"boardedTurn,omitempty"`
+	ExpectedTurns int    `json:"expectedTurns,omitempty"`
+	WaitExpires   int    `json:"-"`
+}
+
+func clonePassengers(in []*PassengerGroup) []*PassengerGroup {
+	if in == nil {
+		return nil
+	}
+	out := make([]*PassengerGroup, 0, len(in))
+	for _, g := range in {
+		if g != nil {
+			c := *g
+			out = append(out, &c)
+		}
+	}
+	return out
+}
+
+func (s *Ship) berths() int { return baseBerths }
+
+// berthsUsed counts passengers aboard plus courier contract passengers bound to the ship.
+func berthsUsed(p *Player, s *Ship) int {
+	used := 0
+	for _, g := range s.Passengers {
+		used += g.Count
+	}
+	for _, m := range p.Missions {
+		if m.Kind == missionCourier && m.ShipID == s.ID {
+			used += m.Quantity
+		}
+	}
+	return used
+}
+
+func passengerFare(room *Room, from, to string, count int) int {
+	return count * (fareBase + farePerUnit*distanceUnits(room, from, to))
+}
+
+// refreshPassengerDemand lets waiting groups give up and new travellers arrive. Called once per turn.
+func (gs *GameServer) refreshPassengerDemand(room *Room) {
+	if room.PassengerDemand == nil {
+		room.PassengerDemand = map[string][]*PassengerGroup{}
+	}
+	names := planetNames(room.Planets)
+	if len(names) < 2 {
+		return
+	}
+	for _, origin := range names {
+		waiting := room.PassengerDemand[origin][:0]
+		for _, g := range room.PassengerDemand[origin] {
+			if room.Turn < g.WaitExpires {
+				waiting = append(waiting, g)
+			}
+		}
+		room.PassengerDemand[origin] = waiting
+		// Busy planets (more production) generate more travellers
+		demand := 1
+		if pl := room.Planets[origin]; pl != nil {
+			output := 0
+			for _, v := range pl.Prod {
+				output += maxInt(0, v)
+			}
+			demand = maxInt(1, output/passengerProdPerGroup)
+		}
+		for i := 0; i < demand && len(room.PassengerDemand[origin]) < passengerGroupsPerPlanet; i++ {
+			dest := names[rand.Intn(len(names))]
+			if dest == origin {
+				continue
+			}
+			count := 1 + rand.Intn(6) // 1-6 travellers
+			room.PassengerDemand[origin] = append(room.PassengerDemand[origin], &PassengerGroup{
+				ID:          "pax_" + randID(),
+				Origin:      origin,
+				Destination: dest,
+				Count:       count,
+				Fare:        passengerFare(room, origin, dest, count),
+				WaitExpires: room.Turn + passengerWaitTurns,
+			})
+		}
+	}
+}
+
+// handleBoardPassengers takes a waiting group aboard a docked ship.
+func (gs *GameServer) handleBoardPassengers(room *Room, p *Player, groupID, shipID string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	ship := p.shipByID(shipID)
+	if ship == nil {
+		return
+	}
+	if ship.InTransit {
+		gs.enqueueModal(p, "In Transit", "Passengers can only board while docked.")
+		return
+	}
+	waiting := room.PassengerDemand[ship.CurrentPlanet]
+	idx := -1
+	for i, g := range waiting {
+		if g.ID == groupID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		gs.enqueueModal(p, "Passengers Gone", "Those travellers found other passage.")
+		return
+	}
+	g := waiting[idx]
+	if free := ship.berths() - berthsUsed(p, ship); g.Count > free {
+		gs.enqueueModal(p, "No Berths", fmt.Sprintf("Only %d berths are free aboard %s.", maxInt(0, free), p.shipLabel(ship)))
+		return
+	}
+	room.PassengerDemand[ship.CurrentPlanet] = append(waiting[:idx:idx], waiting[idx+1:]...)
+	g.BoardedTurn = room.Turn
+	// Travellers expect a direct trip at this ship's speed, plus a turn of slack
+	g.ExpectedTurns = (distanceUnits(room, g.Origin, g.Destination)+ship.speed()-1)/ship.speed() + 1
+	ship.Passengers = append(ship.Passengers, g)
+	gs.logAction(room, p, fmt.Sprintf("Boarded %d passengers for %s (fare $%d)%s", g.Count, g.Destination, g.Fare, shipSuffix(p, ship)))
+}
+
+// disembarkPassengers pays fares for groups whose destination the ship just
+// reached. tripTotal is the leg's TransitTotal, used to report the journey.
+func (gs *GameServer) disembarkPassengers(room *Room, p *Player, ship *Ship, tripTotal int) {
+	if len(ship.Passengers) == 0 {
+		return
+	}
+	kept := ship.Passengers[:0]
+	paid, late := 0, 0
+	for _, g := range ship.Passengers {
+		if g.Destination != ship.CurrentPlanet {
+			kept = append(kept, g)
+			continue
+		}
+		fare := g.Fare
+		if over := room.Turn - g.BoardedTurn - g.ExpectedTurns; over > 0 {
+			pct := maxInt(minFarePct, 100-over*lateFarePenaltyPct)
+			fare = g.Fare * pct / 100
+			late++
+		}
+		paid += fare
+	}
+	ship.Passengers = kept
+	if paid == 0 {
+		return
+	}
+	p.Money += paid
+	msg := fmt.Sprintf("Passenger fares collected at %s: $%d after a %d-unit leg", ship.CurrentPlanet, paid, tripTotal)
+	if late > 0 {
+		msg += fmt.Sprintf(" (%d late groups paid reduced fares)", late)
+	}
+	gs.logAction(room, p, msg+shipSuffix(p, ship))
+}
+
+// passengerEvents rolls random incidents for ships carrying passengers in transit.
+func (gs *GameServer) passengerEvents(room *Room) {
+	for _, p := range room.Players {
+		if p.Bankrupt {
+			continue
+		}
+		for _, ship := range p.ships() {
+			if !ship.InTransit || len(ship.Passengers) == 0 {
+				continue
+			}
+			notify := !p.IsBot
+			switch rand.Intn(pirateRaidChance) {
+			case 0:
+				// Pirates rob the passengers; they'll only pay half fare now
+				for _, g := range ship.Passengers {
+					g.Fare /= 2
+				}
+				gs.logAction(room, p, "Pirate raid: passengers robbed, fares halved"+shipSuffix(p, ship))
+				if notify {
+					gs.enqueueModal(p, "Pirate Raid", fmt.Sprintf("Pirates boarded %s and robbed the passengers. They will only pay half fare on arrival.", p.shipLabel(ship)))
+				}
+			case 1:
+				// A celebrity aboard draws a generous tip
+				tip := 100 + rand.Intn(301)
+				p.Money += tip
+				gs.logAction(room, p, fmt.Sprintf("Celebrity passenger tipped $%d%s", tip, shipSuffix(p, ship)))
+				if notify {
+					gs.enqueueModal(p, "Celebrity Aboard", fmt.Sprintf("A holo-star travelling aboard %s enjoyed the trip and tipped the crew %d credits.", p.shipLabel(ship), tip))
+				}
+			}
+		}
+	}
+}
+
+func passengerDemandPayload(room *Room) map[string][]*PassengerGroup {
+	out := make(map[string][]*PassengerGroup, len(room.PassengerDemand))
+	for planet, groups := range room.PassengerDemand {
+		if len(groups) > 0 {
+			out[planet] = clonePassengers(groups)
+		}
+	}
+	return out
+}
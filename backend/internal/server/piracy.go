@@ -0,0 +1,379 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// Encounter responses
+const (
+	encounterFight   = "fight"
+	encounterFlee    = "flee"
+	encounterTribute = "tribute"
+)
+
+const maxArmamentLevel = 5
+const armamentBasePrice = 1500 // per level, times the level being bought
+const encounterBaseChance = 20 // per mille per ship per transit turn
+const encounterNewsChance = 30 // extra per mille while pirate news is active at either end
+const playerPirateChance = 100 // per mille a lurking player pirate intercepts a passing ship
+const pirateSpeedMin = 15
+const pirateSpeedMax = 35
+const fleeBaseChancePct = 50
+const fightBountyMin = 200
+const fightBountyMax = 800
+const reputationPiracyLoss = 10
+
+// Encounter is a pending pirate interception waiting on the defender's response.
+// Unanswered encounters are settled as tribute on the next turn.
+type Encounter struct {
+	ID         string
+	Defender   PlayerID
+	ShipID     string
+	Pirate     PlayerID // empty for NPC pirates
+	PirateName string
+	Weapons    int
+	Shields    int
+	Speed      int
+	Tribute    int
+	Turn       int
+}
+
+// legRisk is the per-mille chance of an NPC pirate encounter on a leg this turn.
+// The lawless Stations are riskier, the policed Inner Planets safer, and pirate
+// news at either end raises the odds.
+func legRisk(room *Room, from, to string) int {
+	risk := encounterBaseChance
+	if planetFaction(room, from) == factionStations || planetFaction(room, to) == factionStations {
+		risk = risk * 3 / 2
+	}
+	if planetFaction(room, from) == factionInner && planetFaction(room, to) == factionInner {
+		risk /= 2
+	}
+	for _, n := range room.News {
+		if n.PirateRisk > 0 && (n.Planet == from || n.Planet == to) {
+			risk += n.PirateRisk
+		}
+	}
+	return risk
+}
+
+func fleeChance(ship *Ship, e *Encounter) int {
+	return clampInt(fleeBaseChancePct+(ship.speed()-e.Speed)*2, 10, 90)
+}
+
+func findEncounter(room *Room, id string) *Encounter {
+	for _, e := range room.Encounters {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func shipInEncounter(room *Room, pid PlayerID, shipID string) bool {
+	for _, e := range room.Encounters {
+		if e.Defender == pid && e.ShipID == shipID {
+			return true
+		}
+	}
+	return false
+}
+
+// rollEncounters checks every ship still in transit for an interception. Runs after travel.
+func (gs *GameServer) rollEncounters(room *Room) {
+	rolled := map[string]bool{}
+	for _, owner := range room.Players {
+		if owner.Bankrupt {
+			continue
+		}
+		for _, own := range owner.ships() {
+			if !own.InTransit {
+				continue
+			}
+			p, ship, ok := encounterTarget(room, owner, own, rolled)
+			if !ok || shipInEncounter(room, p.ID, ship.ID) {
+				continue
+			}
+			if pirate := lurkingPirate(room, p, ship); pirate != nil && rand.Intn(1000) < playerPirateChance {
+				gs.startEncounter(room, p, ship, &Encounter{
+					Pirate:     pirate.ID,
+					PirateName: pirate.Name,
+					Weapons:    pirate.Weapons,
+					Shields:    pirate.Shields,
+					Speed:      pirate.speed(),
+				})
+				continue
+			}
+			risk := legRisk(room, ship.TransitFrom, ship.DestinationPlanet)
+			if pathHazardous(room, ship) {
+				risk *= 2
+			}
+			if ship.Escort {
+				// Most pirates won't tangle with an escorted ship
+				risk /= 2
+			}
+			if rand.Intn(1000) < risk {
+				gs.startEncounter(room, p, ship, &Encounter{
+					PirateName: "Pirates",
+					Weapons:    rand.Intn(maxArmamentLevel + 1),
+					Shields:    rand.Intn(3),
+					Speed:      pirateSpeedMin + rand.Intn(pirateSpeedMax-pirateSpeedMin+1),
+				})
+			}
+		}
+	}
+}
+
+// lurkingPirate finds a player who has opted into piracy and whose flagship is
+// docked at either end of the ship's current leg.
+func lurkingPirate(room *Room, victim *Player, ship *Ship) *Player {
+	for _, pp := range room.Players {
+		if pp.ID == victim.ID || !pp.Pirate || pp.Bankrupt || pp.InTransit {
+			continue
+		}
+		if pp.CurrentPlanet == ship.TransitFrom || pp.CurrentPlanet == ship.DestinationPlanet {
+			return pp
+		}
+	}
+	return nil
+}
+
+func (gs *GameServer) startEncounter(room *Room, p *Player, ship *Ship, e *Encounter) {
+	e.ID = "encounter_" + randID()
+	e.Defender = p.ID
+	e.ShipID = ship.ID
+	e.Turn = room.Turn
+	e.Tribute = maxInt(200, p.Money*(10+rand.Intn(16))/100) // 10-25% of cash
+	room.Encounters = append(room.Encounters, e)
+	where := "between " + ship.TransitFrom + " and " + ship.DestinationPlanet
+	gs.logAction(room, p, fmt.Sprintf("Intercepted by %s %s%s", e.PirateName, where, shipSuffix(p, ship)))
+	if pirate := room.Players[e.Pirate]; pirate != nil {
+		gs.logAction(room, pirate, fmt.Sprintf("Intercepted %s %s", p.Name, where))
+		gs.adjustReputation(room, pirate, planetFaction(room, pirate.CurrentPlanet), -reputationPiracyLoss, "piracy")
+	}
+	// Bots and hired captains decide on the spot
+	weapons, shields := convoyStrength(room, p, ship)
+	if p.IsBot || ship.Route != nil {
+		gs.resolveEncounter(room, e, botEncounterChoice(weapons+shields, ship, e))
+		return
+	}
+	body := fmt.Sprintf("%s intercepted %s %s. They demand %d credits.\n\nPirate weapons %d, shields %d, speed %d. Yours (with escorts and convoy): weapons %d, shields %d, speed %d.",
+		e.PirateName, p.shipLabel(ship), where, e.Tribute, e.Weapons, e.Shields, e.Speed, weapons, shields, ship.speed())
+	gs.enqueueModal(p, "Pirates!", body)
+	m := &p.Modals[len(p.Modals)-1]
+	m.Kind = "pirate-encounter"
+	m.EncounterID = e.ID
+	m.Price = e.Tribute
+	m.Choices = []ModalChoice{
+		{ID: encounterTribute, Label: "Pay tribute", Description: fmt.Sprintf("Hand over %d credits; cargo makes up any shortfall.", e.Tribute)},
+		{ID: encounterFlee, Label: "Flee", Description: "Outrun them. If caught, they attack.", Chance: fleeChance(ship, e)},
+		{ID: encounterFight, Label: "Fight", Description: "Drive them off for a bounty, or lose cargo and damage the ship."},
+	}
+}
+
+func botEncounterChoice(strength int, ship *Ship, e *Encounter) string {
+	switch {
+	case strength >= e.Weapons+e.Shields:
+		return encounterFight
+	case fleeChance(ship, e) >= 60:
+		return encounterFlee
+	}
+	return encounterTribute
+}
+
+// handleResolveEncounter applies a defender's response to a pending encounter.
+func (gs *GameServer) handleResolveEncounter(room *Room, p *Player, encounterID, action string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	e := findEncounter(room, encounterID)
+	if e == nil || e.Defender != p.ID {
+		return
+	}
+	gs.resolveEncounter(room, e, action)
+}
+
+// settleStaleEncounters pays tribute on encounters left unanswered since an earlier turn.
+func (gs *GameServer) settleStaleEncounters(room *Room) {
+	for _, e := range append([]*Encounter(nil), room.Encounters...) {
+		if e.Turn < room.Turn {
+			gs.resolveEncounter(room, e, encounterTribute)
+		}
+	}
+}
+
+func (gs *GameServer) resolveEncounter(room *Room, e *Encounter, action string) {
+	for i, c := range room.Encounters {
+		if c == e {
+			room.Encounters = append(room.Encounters[:i:i], room.Encounters[i+1:]...)
+			break
+		}
+	}
+	p := room.Players[e.Defender]
+	if p == nil {
+		return
+	}
+	for i, m := range p.Modals {
+		if m.EncounterID == e.ID {
+			p.Modals = append(p.Modals[:i:i], p.Modals[i+1:]...)
+			break
+		}
+	}
+	ship := p.shipByID(e.ShipID)
+	if ship == nil || p.Bankrupt {
+		return
+	}
+	pirate := room.Players[e.Pirate]
+	switch action {
+	case encounterFlee:
+		if rand.Intn(100) < fleeChance(ship, e) {
+			gs.encounterOutcome(room, p, ship, pirate, "Escaped", fmt.Sprintf("%s outran %s.", capitalizeFirst(p.shipLabel(ship)), e.PirateName))
+			return
+		}
+		// Caught: the pirates attack
+		gs.fightEncounter(room, p, ship, e, pirate, "Failed to flee. ")
+	case encounterFight:
+		gs.fightEncounter(room, p, ship, e, pirate, "")
+	default:
+		paid := minInt(e.Tribute, maxInt(0, p.Money))
+		p.Money -= paid
+		if pirate != nil {
+			pirate.Money += paid
+		}
+		msg := fmt.Sprintf("Paid %d credits in tribute to %s.", paid, e.PirateName)
+		lost := 0
+		if paid < e.Tribute {
+			// Short on cash: the pirates make up the difference from the hold
+			var taken string
+			taken, lost = gs.plunderCargo(room, ship, pirate, 25)
+			msg += " " + taken
+		}
+		gs.encounterOutcome(room, p, ship, pirate, "Tribute Paid", msg)
+		gs.claimInsurance(room, p, insuranceCargo, lost, "piracy")
+	}
+}
+
+// fightEncounter compares weapons and shields with a roll each side.
+func (gs *GameServer) fightEncounter(room *Room, p *Player, ship *Ship, e *Encounter, pirate *Player, prefix string) {
+	weapons, shields := convoyStrength(room, p, ship)
+	def := weapons*10 + shields*5 + rand.Intn(40)
+	att := e.Weapons*10 + e.Shields*5 + rand.Intn(40)
+	if def >= att {
+		msg := prefix + fmt.Sprintf("You drove off %s.", e.PirateName)
+		if pirate == nil {
+			bounty := fightBountyMin + rand.Intn(fightBountyMax-fightBountyMin+1)
+			p.Money += bounty
+			msg += fmt.Sprintf(" The Federation paid a %d-credit bounty.", bounty)
+		} else if pirate.Shields > 0 {
+			pirate.Shields--
+			gs.logAction(room, pirate, fmt.Sprintf("Beaten off by %s; shields damaged", p.Name))
+		}
+		gs.encounterOutcome(room, p, ship, pirate, "Pirates Repelled", msg)
+		return
+	}
+	taken, lost := gs.plunderCargo(room, ship, pirate, 50)
+	msg := prefix + fmt.Sprintf("%s overpowered you. ", e.PirateName) + taken
+	if ship.Shields > 0 {
+		ship.Shields--
+		msg += " Your shields were damaged."
+	}
+	gs.encounterOutcome(room, p, ship, pirate, "Defeated by Pirates", msg)
+	gs.claimInsurance(room, p, insuranceCargo, lost, "piracy")
+}
+
+// plunderCargo takes pct percent of every cargo lot; a player pirate keeps what fits.
+// Returns the outcome text and what the stolen cargo cost.
+func (gs *GameServer) plunderCargo(room *Room, ship *Ship, pirate *Player, pct int) (string, int) {
+	taken, value := 0, 0
+	for _, g := range sortedKeys(ship.Inventory) {
+		qty := (ship.Inventory[g]*pct + 99) / 100
+		value += qty * ship.InventoryAvgCost[g]
+		ship.Inventory[g] -= qty
+		if ship.Inventory[g] <= 0 {
+			delete(ship.Inventory, g)
+			delete(ship.InventoryAvgCost, g)
+		}
+		taken += qty
+		if pirate != nil {
+			if fit := minInt(qty, pirate.cargoCapacity()-inventoryTotal(pirate.Inventory)); fit > 0 {
+				old := pirate.Inventory[g]
+				pirate.Inventory[g] = old + fit
+				pirate.InventoryAvgCost[g] = old * pirate.InventoryAvgCost[g] / (old + fit)
+			}
+		}
+	}
+	if taken == 0 {
+		return "Your hold was empty.", 0
+	}
+	return "They took " + strconv.Itoa(taken) + " units of cargo.", value
+}
+
+func (gs *GameServer) encounterOutcome(room *Room, p *Player, ship *Ship, pirate *Player, title, msg string) {
+	gs.logAction(room, p, "Pirate encounter: "+msg+shipSuffix(p, ship))
+	if !p.IsBot {
+		gs.enqueueModal(p, title, msg)
+	}
+	if pirate != nil {
+		gs.logAction(room, pirate, fmt.Sprintf("Piracy against %s: %s", p.Name, title))
+		if !pirate.IsBot {
+			gs.enqueueModal(pirate, "Piracy Report", fmt.Sprintf("Your attack on %s ended: %s", p.Name, msg))
+		}
+	}
+}
+
+func capitalizeFirst(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
+
+// handleBuyArmament fits weapons or shields to a docked ship.
+func (gs *GameServer) handleBuyArmament(room *Room, p *Player, shipID, kind string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	ship := p.shipByID(shipID)
+	if ship == nil {
+		return
+	}
+	if ship.InTransit {
+		gs.enqueueModal(p, "In Transit", "Armaments can only be fitted while docked.")
+		return
+	}
+	level := &ship.Weapons
+	if kind == "shields" {
+		level = &ship.Shields
+	} else if kind != "weapons" {
+		return
+	}
+	if *level >= maxArmamentLevel {
+		gs.enqueueModal(p, "Fully Armed", fmt.Sprintf("%s already has level %d %s.", capitalizeFirst(p.shipLabel(ship)), maxArmamentLevel, kind))
+		return
+	}
+	price := armamentBasePrice * (*level + 1)
+	if spendableFunds(p) < price {
+		gs.enqueueModal(p, "Insufficient Funds", fmt.Sprintf("Level %d %s cost %d credits.", *level+1, kind, price))
+		return
+	}
+	p.Money -= price
+	*level++
+	p.UpgradeInvestment += price
+	recordDeduction(p, deductUpgrades, price)
+	gs.logAction(room, p, fmt.Sprintf("Fitted level %d %s for $%d%s", *level, kind, price, shipSuffix(p, ship)))
+}
+
+// handleSetPiracy opts the player in or out of intercepting other captains.
+func (gs *GameServer) handleSetPiracy(room *Room, p *Player, enabled bool) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	if p.Pirate == enabled {
+		return
+	}
+	p.Pirate = enabled
+	if enabled {
+		gs.logAction(room, p, "Raised the black flag: now intercepting ships passing your port")
+	} else {
+		gs.logAction(room, p, "Gave up piracy")
+	}
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const recipeBatchesPerTurn = 5 // batches a Refinery or Factory can run each turn
+
+// Recipe turns a set of input goods into a higher-value output good.
+type Recipe struct {
+	ID        string         `json:"id"`
+	Inputs    map[string]int `json:"inputs"`
+	Output    string         `json:"output"`
+	OutputQty int            `json:"outputQty"`
+}
+
+// productionRecipes is the fixed recipe book. Several inputs are location-unique
+// goods, so running a chain means hauling them in from where they're produced.
+var productionRecipes = []Recipe{
+	{ID: "noodles", Inputs: map[string]int{"Sky Kelp": 1, "Desalinated Sodium": 1}, Output: "Zero-G Noodles", OutputQty: 2},
+	{ID: "coffee", Inputs: map[string]int{"Moon Ferns": 1, "Nano Lint": 1}, Output: "Cosmic Coffee Beans", OutputQty: 2},
+	{ID: "socks", Inputs: map[string]int{"Reticulated Splines": 1, "Cyber Toasters": 1}, Output: "Photon Socks", OutputQty: 2},
+	{ID: "honey", Inputs: map[string]int{"Nebula Nectar": 1, "Quantum Bubblegum": 1}, Output: "Holographic Honey", OutputQty: 2},
+	{ID: "donuts", Inputs: map[string]int{"Zero-G Noodles": 1, "Stellar Marshmallows": 1}, Output: "Plasma Donuts", OutputQty: 2},
+	{ID: "hotsauce", Inputs: map[string]int{"Orbital Oregano": 1, "Laser Lemons": 1}, Output: "Alien Hot Sauce", OutputQty: 2},
+	{ID: "rations", Inputs: map[string]int{"Void Raisins": 1, "Ring Popcorn": 1, "Zero-G Noodles": 1}, Output: "Rocket Rations", OutputQty: 3},
+	{ID: "crystals", Inputs: map[string]int{"Singularity Seeds": 1, "Gamma Grit": 1}, Output: "Chrono Crystals", OutputQty: 2},
+}
+
+func recipeByID(id string) *Recipe {
+	for i := range productionRecipes {
+		if productionRecipes[i].ID == id {
+			return &productionRecipes[i]
+		}
+	}
+	return nil
+}
+
+func runsRecipes(f *Facility) bool {
+	return f.Type == "Refinery" || f.Type == "Factory"
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *Recipe) describe() string {
+	parts := make([]string, 0, len(r.Inputs))
+	for _, g := range sortedKeys(r.Inputs) {
+		parts = append(parts, fmt.Sprintf("%d %s", r.Inputs[g], g))
+	}
+	return fmt.Sprintf("%s -> %d %s", strings.Join(parts, " + "), r.OutputQty, r.Output)
+}
+
+// runProductionChains runs the selected recipe of every Refinery and Factory.
+// Inputs come from the facility's warehouse first, then from planet stock bought
+// at market price with the owner's cash. Output lands in the warehouse.
+func (gs *GameServer) runProductionChains(room *Room) {
+	for _, pl := range room.Planets {
+		if pl == nil {
+			continue
+		}
+		for _, f := range pl.Facilities {
+			if f == nil || !runsRecipes(f) || f.Recipe == "" {
+				continue
+			}
+			r := recipeByID(f.Recipe)
+			if r == nil {
+				continue
+			}
+			if f.Storage == nil {
+				f.Storage = map[string]int{}
+			}
+			owner := room.Players[f.Owner]
+			if owner != nil && owner.Bankrupt {
+				owner = nil
+			}
+			batches, spent := 0, 0
+			for batches < recipeBatchesPerTurn {
+				cost, ok := gs.gatherRecipeInputs(pl, f, r, owner)
+				if !ok {
+					break
+				}
+				spent += cost
+				f.Storage[r.Output] += r.OutputQty
+				batches++
+			}
+			if batches > 0 && owner != nil {
+				msg := fmt.Sprintf("%s on %s produced %d %s", f.Type, pl.Name, batches*r.OutputQty, r.Output)
+				if spent > 0 {
+					msg += fmt.Sprintf(" ($%d of inputs bought locally)", spent)
+				}
+				gs.logAction(room, owner, msg)
+			}
+		}
+	}
+}
+
+// gatherRecipeInputs consumes one batch of inputs. It checks availability first so a
+// batch is either fully consumed or not touched. Returns the cash spent on planet stock.
+func (gs *GameServer) gatherRecipeInputs(pl *Planet, f *Facility, r *Recipe, owner *Player) (int, bool) {
+	cost := 0
+	for g, need := range r.Inputs {
+		short := maxInt(0, need-f.Storage[g])
+		if short == 0 {
+			continue
+		}
+		if owner == nil || pl.Goods[g] < short {
+			return 0, false
+		}
+		cost += short * pl.Prices[g]
+	}
+	if owner != nil && cost > owner.Money {
+		return 0, false
+	}
+	for g, need := range r.Inputs {
+		fromStore := minInt(need, f.Storage[g])
+		f.Storage[g] -= fromStore
+		if f.Storage[g] == 0 {
+			delete(f.Storage, g)
+		}
+		pl.Goods[g] -= need - fromStore
+	}
+	if owner != nil {
+		owner.Money -= cost
+	}
+	return cost, true
+}
+
+// handleSetRecipe lets a Refinery or Factory owner choose what it produces.
+func (gs *GameServer) handleSetRecipe(room *Room, p *Player, facilityID, recipeID string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	planet, f := findFacility(room, facilityID)
+	if f == nil || f.Owner != p.ID {
+		return
+	}
+	if !runsRecipes(f) {
+		gs.enqueueModal(p, "No Production Line", "Only Refineries and Factories can run recipes.")
+		return
+	}
+	if recipeID == "" {
+		f.Recipe = ""
+		gs.logAction(room, p, fmt.Sprintf("Stopped production at %s on %s", f.Type, planet.Name))
+		return
+	}
+	r := recipeByID(recipeID)
+	if r == nil {
+		return
+	}
+	f.Recipe = r.ID
+	gs.logAction(room, p, fmt.Sprintf("%s on %s now produces %s", f.Type, planet.Name, r.describe()))
+}
+
+// handleFacilityStorage moves goods between a docked ship and the facility warehouse.
+// Positive amounts deposit, negative amounts withdraw.
+func (gs *GameServer) handleFacilityStorage(room *Room, p *Player, facilityID, shipID, good string, amount int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	planet, f := findFacility(room, facilityID)
+	if f == nil || f.Owner != p.ID || good == "" || amount == 0 {
+		return
+	}
+	ship := p.shipByID(shipID)
+	if ship == nil || ship.InTransit || ship.CurrentPlanet != planet.Name {
+		gs.enqueueModal(p, "Not Docked", "A ship must be docked at "+planet.Name+" to move goods in or out of the warehouse.")
+		return
+	}
+	if f.Storage == nil {
+		f.Storage = map[string]int{}
+	}
+	if amount > 0 {
+		amount = minInt(amount, ship.Inventory[good])
+		if amount <= 0 {
+			return
+		}
+		ship.Inventory[good] -= amount
+		if ship.Inventory[good] == 0 {
+			delete(ship.Inventory, good)
+			delete(ship.InventoryAvgCost, good)
+		}
+		f.Storage[good] += amount
+		gs.logAction(room, p, fmt.Sprintf("Deposited %d %s at %s on %s%s", amount, good, f.Type, planet.Name, shipSuffix(p, ship)))
+		return
+	}
+	amount = minInt(-amount, f.Storage[good])
+	amount = minInt(amount, ship.cargoCapacity()-inventoryTotal(ship.Inventory))
+	if amount <= 0 {
+		return
+	}
+	f.Storage[good] -= amount
+	if f.Storage[good] == 0 {
+		delete(f.Storage, good)
+	}
+	// Warehouse goods carry no purchase cost; book them at the local price
+	oldQty := ship.Inventory[good]
+	newQty := oldQty + amount
+	ship.Inventory[good] = newQty
+	ship.InventoryAvgCost[good] = (oldQty*ship.InventoryAvgCost[good] + amount*planet.Prices[good]) / newQty
+	gs.logAction(room, p, fmt.Sprintf("Withdrew %d %s from %s on %s%s", amount, good, f.Type, planet.Name, shipSuffix(p, ship)))
+}
+
+// recipesPayload lists the recipe book for room state.
+func recipesPayload() []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(productionRecipes))
+	for _, r := range productionRecipes {
+		out = append(out, map[string]interface{}{
+			"id":        r.ID,
+			"inputs":    cloneIntMap(r.Inputs),
+			"output":    r.Output,
+			"outputQty": r.OutputQty,
+		})
+	}
+	return out
+}
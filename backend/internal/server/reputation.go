@@ -0,0 +1,279 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Factions
+const (
+	factionInner    = "Inner Planets"
+	factionOuter    = "Outer Planets"
+	factionStations = "Stations"
+)
+
+var factions = []string{factionInner, factionOuter, factionStations}
+
+const maxReputation = 100
+const reputationPricePct = 10      // price swing at full reputation either way
+const reputationTradeStep = 1000   // credits of legitimate trade per reputation point
+const reputationMissionGain = 5    // completing a contract
+const reputationMissionLoss = 5    // failing or abandoning a contract
+const reputationContrabandLoss = 5 // per contraband trade
+const reputationStingLoss = 15     // exposed by a Federation sting
+const reputationMissedPaymentLoss = 5
+const reputationRepossessionLoss = 10
+const reputationTrustedLevel = 50  // halves dock tax
+const reputationHostileLevel = -50 // doubles dock tax and bars auctions
+const reputationMissionMinLevel = -25
+
+const innerFactionShare = 3 // one in this many generated systems, nearest the centre, join the Inner Planets
+
+var innerPlanets = map[string]bool{"Mercury": true, "Venus": true, "Earth": true, "Mars": true}
+
+// factionContraband lists goods each faction bans. Trading them at that faction's
+// planets still works but costs reputation.
+var factionContraband = map[string][]string{
+	factionInner:    {"Wormhole Licorice", "Extradimensional Sea Monkeys"},
+	factionOuter:    {"Depleted Clown Shoes", "Martian Dust Bunnies"},
+	factionStations: {"Galactic Jelly", "Anti-Gravity Paperclips"},
+}
+
+func isStation(name string) bool {
+	return strings.HasSuffix(name, " Station")
+}
+
+// classicFaction assigns the classic solar system: stations by name, the four
+// rocky worlds to the Inner Planets, and everything else to the Outer Planets.
+func classicFaction(name string) string {
+	if isStation(name) {
+		return factionStations
+	}
+	if innerPlanets[name] {
+		return factionInner
+	}
+	return factionOuter
+}
+
+// assignFactions sorts a generated galaxy into factions: stations by name, the
+// systems nearest the centre to the Inner Planets and the rest to the Outer Planets.
+func assignFactions(planets map[string]*Planet, pos map[string][2]float64) {
+	worlds := []string{}
+	for _, n := range sortedPositionNames(pos) {
+		if pl := planets[n]; pl != nil {
+			if isStation(n) {
+				pl.Faction = factionStations
+			} else {
+				worlds = append(worlds, n)
+			}
+		}
+	}
+	radius := func(n string) float64 {
+		return math.Hypot(pos[n][0]-orbitCenter, pos[n][1]-orbitCenter)
+	}
+	sort.SliceStable(worlds, func(i, j int) bool { return radius(worlds[i]) < radius(worlds[j]) })
+	inner := maxInt(1, len(worlds)/innerFactionShare)
+	for i, n := range worlds {
+		planets[n].Faction = factionOuter
+		if i < inner {
+			planets[n].Faction = factionInner
+		}
+	}
+}
+
+// planetFaction is the faction holding a location in the room.
+func planetFaction(room *Room, name string) string {
+	if pl := room.Planets[name]; pl != nil && pl.Faction != "" {
+		return pl.Faction
+	}
+	return classicFaction(name)
+}
+
+func isContraband(room *Room, planet, good string) bool {
+	return containsGood(factionContraband[planetFaction(room, planet)], good)
+}
+
+func reputationAt(room *Room, p *Player, planet string) int {
+	return p.Reputation[planetFaction(room, planet)]
+}
+
+// adjustReputation moves a player's standing with one faction, logging the change.
+func (gs *GameServer) adjustReputation(room *Room, p *Player, faction string, delta int, why string) {
+	if delta == 0 || p == nil {
+		return
+	}
+	if p.Reputation == nil {
+		p.Reputation = map[string]int{}
+	}
+	before := p.Reputation[faction]
+	p.Reputation[faction] = clampInt(before+delta, -maxReputation, maxReputation)
+	if p.Reputation[faction] == before {
+		return
+	}
+	gs.logAction(room, p, fmt.Sprintf("Reputation with %s %+d (%s)", faction, p.Reputation[faction]-before, why))
+}
+
+func (gs *GameServer) adjustAllReputation(room *Room, p *Player, delta int, why string) {
+	for _, f := range factions {
+		gs.adjustReputation(room, p, f, delta, why)
+	}
+}
+
+// repBuyPrice is what a player pays per unit at a planet given their standing there.
+func repBuyPrice(room *Room, p *Player, planet string, price int) int {
+	return maxInt(1, price*(100-reputationAt(room, p, planet)*reputationPricePct/maxReputation)/100)
+}
+
+// repSellPrice is what a planet pays a player per unit given their standing there.
+// Goods bought at the same market are priced by repSale instead.
+func repSellPrice(room *Room, p *Player, planet string, price int) int {
+	return maxInt(1, price*(100+reputationAt(room, p, planet)*reputationPricePct/maxReputation)/100)
+}
+
+// buyLedger counts, per good and then per planet, units a player bought at that
+// market and may still be carrying.
+type buyLedger map[string]map[string]int
+
+// recordPurchase notes goods bought at a market, so reselling them there can be
+// recognised, and adjusts reputation for the trade.
+func (gs *GameServer) recordPurchase(room *Room, p *Player, planet, good string, qty, cost int) {
+	if p.MarketBuys == nil {
+		p.MarketBuys = buyLedger{}
+	}
+	if p.MarketBuys[good] == nil {
+		p.MarketBuys[good] = map[string]int{}
+	}
+	p.MarketBuys[good][planet] += qty
+	gs.recordTrade(room, p, planet, good, cost)
+}
+
+// marketResale works out how many of qty units being sold at planet were bought
+// there, and books the sale against the player's purchase records. Call it before
+// the goods leave the hold.
+func marketResale(p *Player, planet, good string, qty int) int {
+	buys := p.MarketBuys[good]
+	if len(buys) == 0 {
+		return 0
+	}
+	held := 0
+	for _, s := range p.ships() {
+		held += s.Inventory[good]
+	}
+	resold := minInt(qty, minInt(buys[planet], held))
+	buys[planet] -= resold
+	rest, left := qty-resold, held-qty
+	for _, from := range sortedKeys(buys) {
+		take := minInt(rest, buys[from])
+		rest -= take
+		buys[from] = minInt(buys[from]-take, left)
+		if buys[from] <= 0 {
+			delete(buys, from)
+		}
+	}
+	return resold
+}
+
+// repSale prices a sale of qty units at the player's standing and adjusts
+// reputation, returning the proceeds. Units bought at the same market fetch no
+// more than it charges for them and take back the standing their purchase earned,
+// so buying and reselling in place farms neither profit nor reputation. Call it
+// before the goods leave the hold.
+func (gs *GameServer) repSale(room *Room, p *Player, planet, good string, price, qty int) int {
+	sell := repSellPrice(room, p, planet, price)
+	back := minInt(sell, repBuyPrice(room, p, planet, price))
+	resold := marketResale(p, planet, good, qty)
+	if resold > 0 && p.TradeProgress != nil && !isContraband(room, planet, good) {
+		p.TradeProgress[planetFaction(room, planet)] -= resold * back
+	}
+	gs.recordTrade(room, p, planet, good, (qty-resold)*sell)
+	return resold*back + (qty-resold)*sell
+}
+
+// recordTrade adjusts reputation after a market transaction: contraband costs
+// standing, legitimate trade slowly builds it.
+func (gs *GameServer) recordTrade(room *Room, p *Player, planet, good string, value int) {
+	faction := planetFaction(room, planet)
+	if isContraband(room, planet, good) {
+		gs.adjustReputation(room, p, faction, -reputationContrabandLoss, "traded contraband "+good)
+		return
+	}
+	if p.TradeProgress == nil {
+		p.TradeProgress = map[string]int{}
+	}
+	p.TradeProgress[faction] += value
+	if gain := p.TradeProgress[faction] / reputationTradeStep; gain > 0 {
+		p.TradeProgress[faction] %= reputationTradeStep
+		gs.adjustReputation(room, p, faction, gain, "legitimate trade")
+	}
+}
+
+// dockTaxAt is the per-turn docking fee for the player's standing at a planet.
+func dockTaxAt(room *Room, p *Player, planet string) int {
+	rep := reputationAt(room, p, planet)
+	switch {
+	case rep >= reputationTrustedLevel:
+		return 5
+	case rep <= reputationHostileLevel:
+		return 20
+	}
+	return 10
+}
+
+// auctionBarred reports whether the faction hosting an auction refuses the player's bids.
+func auctionBarred(room *Room, p *Player, a *FederationAuction) bool {
+	return reputationAt(room, p, a.Planet) <= reputationHostileLevel
+}
+
+func cloneReputation(in map[string]int) map[string]int {
+	if in == nil {
+		return nil
+	}
+	return cloneIntMap(in)
+}
+
+func cloneBuyLedger(in buyLedger) buyLedger {
+	if in == nil {
+		return nil
+	}
+	out := make(buyLedger, len(in))
+	for g, buys := range in {
+		out[g] = cloneIntMap(buys)
+	}
+	return out
+}
+
+// reputationPayload lists the player's standing with each faction.
+func reputationPayload(p *Player) map[string]int {
+	out := make(map[string]int, len(factions))
+	for _, f := range factions {
+		out[f] = p.Reputation[f]
+	}
+	return out
+}
+
+// factionsPayload maps each planet to its faction and lists the banned goods
+// traded in the room.
+func factionsPayload(room *Room) map[string]interface{} {
+	planets := map[string]string{}
+	for name := range room.Planets {
+		planets[name] = planetFaction(room, name)
+	}
+	ranges := priceRanges(room)
+	contraband := map[string][]string{}
+	for f, goods := range factionContraband {
+		c := []string{}
+		for _, g := range goods {
+			if _, ok := ranges[g]; ok {
+				c = append(c, g)
+			}
+		}
+		sort.Strings(c)
+		contraband[f] = c
+	}
+	return map[string]interface{}{
+		"planets":    planets,
+		"contraband": contraband,
+	}
+}
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+)
+
+const captainWage = 40       // credits per turn paid to a hired route captain
+const routeCashReserve = 300 // captains never spend the owner's last credits on cargo
+const maxRouteStops = 8
+
+// RouteStop is one stop on an automated trade route. Buy/Sell list the goods to
+// trade at this stop; the wildcard "*" lets the captain decide using the same
+// price thresholds the bots use.
+type RouteStop struct {
+	Planet string   `json:"planet"`
+	Buy    []string `json:"buy,omitempty"`
+	Sell   []string `json:"sell,omitempty"`
+	Refuel bool     `json:"refuel"`
+}
+
+// TradeRoute is an ordered loop of stops run every turn by a hired captain on a fleet ship.
+type TradeRoute struct {
+	Stops     []RouteStop
+	NextStop  int
+	HiredTurn int
+	Wage      int
+	// Running profit and loss
+	Revenue   int
+	Purchases int
+	FuelCosts int
+	WagesPaid int
+	StopsRun  int
+	Stranded  bool // waiting at port without fuel for the next leg; no wages accrue
+	// Captain's own market memory, fed by updateBotPriceMemory
+	PriceMemory    map[string]*PriceMemory
+	lastStopProfit int
+}
+
+func (r *TradeRoute) net() int { return r.Revenue - r.Purchases - r.FuelCosts - r.WagesPaid }
+
+func cloneTradeRoute(r *TradeRoute) *TradeRoute {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Stops = make([]RouteStop, len(r.Stops))
+	for i, st := range r.Stops {
+		c.Stops[i] = RouteStop{
+			Planet: st.Planet,
+			Buy:    append([]string(nil), st.Buy...),
+			Sell:   append([]string(nil), st.Sell...),
+			Refuel: st.Refuel,
+		}
+	}
+	if r.PriceMemory != nil {
+		c.PriceMemory = make(map[string]*PriceMemory, len(r.PriceMemory))
+		for k, v := range r.PriceMemory {
+			if v == nil {
+				continue
+			}
+			mem := *v
+			mem.Prices = cloneIntMap(v.Prices)
+			mem.LastPurchased = cloneIntMap(v.LastPurchased)
+			mem.PurchaseAmounts = cloneIntMap(v.PurchaseAmounts)
+			mem.ProfitHistory = append([]int(nil), v.ProfitHistory...)
+			c.PriceMemory[k] = &mem
+		}
+	}
+	return &c
+}
+
+// handleSetRoute hires a captain for a fleet ship and assigns it a trade route.
+func (gs *GameServer) handleSetRoute(room *Room, p *Player, shipID string, stops []RouteStop) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	ship := p.shipByID(shipID)
+	if ship == nil || p.isFlagship(ship) {
+		gs.enqueueModal(p, "No Captain Available", "Captains can only be hired for ships in your fleet, not your flagship.")
+		return
+	}
+	if len(stops) < 2 || len(stops) > maxRouteStops {
+		gs.enqueueModal(p, "Invalid Route", fmt.Sprintf("A trade route needs between 2 and %d stops.", maxRouteStops))
+		return
+	}
+	clean := make([]RouteStop, 0, len(stops))
+	for i, st := range stops {
+		if room.Planets[st.Planet] == nil {
+			gs.enqueueModal(p, "Invalid Route", "Unknown planet on route: "+defaultStr(st.Planet, "(blank)")+".")
+			return
+		}
+		if i > 0 && clean[i-1].Planet == st.Planet {
+			gs.enqueueModal(p, "Invalid Route", "Consecutive stops must be different planets.")
+			return
+		}
+		clean = append(clean, RouteStop{
+			Planet: st.Planet,
+			Buy:    append([]string(nil), st.Buy...),
+			Sell:   append([]string(nil), st.Sell...),
+			Refuel: st.Refuel,
+		})
+	}
+	ship.Route = &TradeRoute{
+		Stops:       clean,
+		HiredTurn:   room.Turn,
+		Wage:        captainWage,
+		PriceMemory: make(map[string]*PriceMemory),
+	}
+	gs.logAction(room, p, fmt.Sprintf("Hired a captain for %s on a %d-stop route ($%d/turn)", ship.Name, len(clean), captainWage))
+	gs.enqueueModal(p, "Captain Hired", fmt.Sprintf("A captain takes command of the %s and will run your %d-stop route for %d credits per turn.", ship.Name, len(clean), captainWage))
+}
+
+// handleClearRoute dismisses the captain of a fleet ship; the ship stays where it is.
+func (gs *GameServer) handleClearRoute(room *Room, p *Player, shipID string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
+	ship := p.shipByID(shipID)
+	if ship == nil || ship.Route == nil {
+		return
+	}
+	r := ship.Route
+	ship.Route = nil
+	gs.logAction(room, p, fmt.Sprintf("Dismissed captain of %s (route net $%d)", ship.Name, r.net()))
+}
+
+// runTradeRoutes pays captain wages and executes the current stop of every
+// automated route. Called once per turn from the ticker after travel resolves.
+func (gs *GameServer) runTradeRoutes(room *Room) {
+	for _, p := range room.Players {
+		if p.Bankrupt {
+			continue
+		}
+		for _, ship := range p.Fleet {
+			if ship == nil || ship.Route == nil {
+				continue
+			}
+			r := ship.Route
+			if !r.Stranded {
+				r.WagesPaid += r.Wage
+				gs.chargeWithCredit(room, p, r.Wage, "captain wages", ship.CurrentPlanet)
+				if p.Bankrupt {
+					break
+				}
+			}
+			if ship.InTransit || len(r.Stops) == 0 {
+				continue
+			}
+			if r.NextStop >= len(r.Stops) {
+				r.NextStop = 0
+			}
+			stop := r.Stops[r.NextStop]
+			if ship.CurrentPlanet == stop.Planet {
+				gs.executeRouteStop(room, p, ship, stop)
+				r.StopsRun++
+				r.NextStop = (r.NextStop + 1) % len(r.Stops)
+				stop = r.Stops[r.NextStop]
+			}
+			gs.dispatchRouteShip(room, p, ship, stop.Planet)
+		}
+	}
+}
+
+// executeRouteStop sells, refuels and buys at a route stop.
+func (gs *GameServer) executeRouteStop(room *Room, p *Player, ship *Ship, stop RouteStop) {
+	r := ship.Route
+	planet := room.Planets[ship.CurrentPlanet]
+	if planet == nil {
+		return
+	}
+	if r.PriceMemory == nil {
+		r.PriceMemory = make(map[string]*PriceMemory)
+	}
+	updateBotPriceMemory(r.PriceMemory, ship.CurrentPlanet, planet, room.Turn, r.net(), &r.lastStopProfit)
+	emergencyMode := p.Money < 200
+	lowMoneyMode := p.Money < 500
+
+	// Sell first so proceeds can fund fuel and new cargo
+	sellAll := containsGood(stop.Sell, "*")
+	goods := make([]string, 0, len(ship.Inventory))
+	for g := range ship.Inventory {
+		goods = append(goods, g)
+	}
+	sort.Strings(goods)
+	for _, g := range goods {
+		qty := ship.Inventory[g]
+		price := planet.Prices[g]
+		if qty <= 0 || price <= 0 {
+			continue
+		}
+		// Judge the sale at what this market will actually pay the owner
+		offer := repSellPrice(room, p, planet.Name, price)
+		if !containsGood(stop.Sell, g) && !(sellAll && botShouldSell(r.PriceMemory, priceRanges(room), g, offer, ship.InventoryAvgCost[g], emergencyMode, lowMoneyMode)) {
+			continue
+		}
+		proceeds := gs.repSale(room, p, planet.Name, g, price, qty)
+		ship.Inventory[g] -= qty
+		planet.Goods[g] += qty
+		p.Money += proceeds
+		recordSale(p, proceeds, qty*ship.InventoryAvgCost[g])
+		r.Revenue += proceeds
+		delete(ship.Inventory, g)
+		delete(ship.InventoryAvgCost, g)
+		gs.logAction(room, p, fmt.Sprintf("Sold %d %s for $%d%s", qty, g, proceeds, shipSuffix(p, ship)))
+	}
+
+	// Refuel to capacity when the stop asks for it, or whenever the tank can't reach the next stop
+	next := r.Stops[(r.NextStop+1)%len(r.Stops)]
+	need := departureUnits(room, ship.CurrentPlanet, next.Planet)
+	if stop.Refuel || ship.Fuel < need {
+		gs.refuelRouteShip(room, p, ship, planet)
+	}
+
+	// Buy listed goods (or bot-approved goods for "*") with whatever the owner can spare
+	buyAny := containsGood(stop.Buy, "*")
+	keys := make([]string, 0, len(planet.Goods))
+	for g := range planet.Goods {
+		keys = append(keys, g)
+	}
+	sort.Strings(keys)
+	for _, g := range keys {
+		price := planet.Prices[g]
+		if price <= 0 {
+			continue
+		}
+		if !containsGood(stop.Buy, g) && !(buyAny && botShouldBuy(r.PriceMemory, priceRanges(room), ship.CurrentPlanet, planet, g, price, emergencyMode, lowMoneyMode)) {
+			continue
+		}
+		free := ship.cargoCapacity() - inventoryTotal(ship.Inventory)
+		if free <= 0 {
+			break
+		}
+		price = repBuyPrice(room, p, planet.Name, price)
+		budget := p.Money - routeCashReserve
+		amount := minInt(minInt(free, planet.Goods[g]), budget/price)
+		if amount <= 0 {
+			continue
+		}
+		cost := amount * price
+		p.Money -= cost
+		r.Purchases += cost
+		planet.Goods[g] -= amount
+		oldQty := ship.Inventory[g]
+		oldAvg := ship.InventoryAvgCost[g]
+		newQty := oldQty + amount
+		ship.Inventory[g] = newQty
+		ship.InventoryAvgCost[g] = (oldQty*oldAvg + amount*price) / newQty
+		recordBotPurchase(r.PriceMemory, ship.CurrentPlanet, g, amount, room.Turn)
+		gs.recordPurchase(room, p, planet.Name, g, amount, cost)
+		gs.logAction(room, p, fmt.Sprintf("Bought %d %s for $%d%s", amount, g, cost, shipSuffix(p, ship)))
+	}
+}
+
+// refuelRouteShip fills a route ship's tank with whatever the owner can afford.
+func (gs *GameServer) refuelRouteShip(room *Room, p *Player, ship *Ship, planet *Planet) {
+	fp := planet.FuelPrice
+	if fp <= 0 {
+		fp = 10
+	}
+	units := minInt(ship.maxFuel()-ship.Fuel, maxInt(0, spendableFunds(p))/fp)
+	if units <= 0 {
+		return
+	}
+	cost := units * fp
+	p.Money -= cost
+	ship.Fuel += units
+	ship.Route.FuelCosts += cost
+	gs.logAction(room, p, fmt.Sprintf("Refueled %d units for $%d at %s%s", units, cost, ship.CurrentPlanet, shipSuffix(p, ship)))
+}
+
+// dispatchRouteShip sends a route ship toward its next stop if it has the fuel. A
+// stranded ship retries refuelling each turn and is reported once.
+func (gs *GameServer) dispatchRouteShip(room *Room, p *Player, ship *Ship, dest string) {
+	if ship.CurrentPlanet == dest || ship.DestinationPlanet == dest {
+		return
+	}
+	r := ship.Route
+	dist := departureUnits(room, ship.CurrentPlanet, dest)
+	if dist > ship.Fuel && r.Stranded {
+		if planet := room.Planets[ship.CurrentPlanet]; planet != nil {
+			gs.refuelRouteShip(room, p, ship, planet)
+		}
+	}
+	if dist > ship.Fuel {
+		if !r.Stranded {
+			r.Stranded = true
+			gs.logAction(room, p, fmt.Sprintf("%s is stranded at %s without fuel for %s", ship.Name, ship.CurrentPlanet, dest))
+		}
+		return
+	}
+	r.Stranded = false
+	ship.DestinationPlanet = dest
+}
+
+// routeReportPayload summarises a route's profit and loss for the owner.
+func routeReportPayload(room *Room, ship *Ship) map[string]interface{} {
+	r := ship.Route
+	if r == nil {
+		return nil
+	}
+	turns := maxInt(1, room.Turn-r.HiredTurn)
+	stops := make([]map[string]interface{}, 0, len(r.Stops))
+	for _, st := range r.Stops {
+		stops = append(stops, map[string]interface{}{
+			"planet": st.Planet,
+			"buy":    append([]string(nil), st.Buy...),
+			"sell":   append([]string(nil), st.Sell...),
+			"refuel": st.Refuel,
+		})
+	}
+	return map[string]interface{}{
+		"shipId":      ship.ID,
+		"shipName":    ship.Name,
+		"stops":       stops,
+		"nextStop":    r.NextStop,
+		"wage":        r.Wage,
+		"turns":       turns,
+		"stopsRun":    r.StopsRun,
+		"revenue":     r.Revenue,
+		"purchases":   r.Purchases,
+		"fuelCosts":   r.FuelCosts,
+		"wagesPaid":   r.WagesPaid,
+		"net":         r.net(),
+		"stranded":    r.Stranded,
+		"netPerTurn":  r.net() / turns,
+		"cargoOnHand": inventoryValue(ship.Inventory, ship.InventoryAvgCost),
+	}
+}
+
+func containsGood(list []string, good string) bool {
+	for _, g := range list {
+		if g == good {
+			return true
+		}
+	}
+	return false
+}
@@ -79,32 +79,36 @@ type MarketSnapshot struct {
 }
 
 type Player struct {
-	ID                PlayerID        `json:"id"`
-	Name              string          `json:"name"`
-	Money             int             `json:"money"`
-	CurrentPlanet     string          `json:"currentPlanet"`
-	DestinationPlanet string          `json:"destinationPlanet"`
-	Inventory         map[string]int  `json:"inventory"`
-	InventoryAvgCost  map[string]int  `json:"inventoryAvgCost"`
-	Ready             bool            `json:"ready"`
-	EndGame           bool            `json:"endGame"`
-	Modals            []ModalItem     `json:"-"`
-	Fuel              int             `json:"fuel"`
-	conn              *websocket.Conn // not serialized
-	roomID            string          // not serialized
-	IsBot             bool            `json:"-"`
-	writeMu           sync.Mutex      // guards conn writes
-	Bankrupt          bool            `json:"-"`
-	// Transit state (server-only)
-	InTransit          bool   `json:"-"`
-	TransitFrom        string `json:"-"`
-	TransitRemaining   int    `json:"-"` // units remaining to destination along straight line
-	TransitTotal       int    `json:"-"` // initial units at start of transit
-	CapacityBonus      int    `json:"-"`
-	SpeedBonus         int    `json:"-"`
-	FuelCapacityBonus  int    `json:"-"`
-	FacilityInvestment int    `json:"-"`
-	UpgradeInvestment  int    `json:"-"`
+	ID      PlayerID        `json:"id"`
+	Name    string          `json:"name"`
+	Money   int             `json:"money"`
+	Ready   bool            `json:"ready"`
+	EndGame bool            `json:"endGame"`
+	Modals  []ModalItem     `json:"-"`
+	conn    *websocket.Conn // not serialized
+	roomID  string          // not serialized
+	IsBot   bool            `json:"-"`
+	writeMu sync.Mutex      // guards conn writes
+	// Flagship state (location, cargo, fuel, transit, upgrades) is promoted from Ship
+	Ship
+	// Additional ships beyond the flagship
+	Fleet              []*Ship          `json:"-"`
+	FleetInvestment    int              `json:"-"`
+	Loan               *Loan            `json:"-"`
+	Missions           []*Mission       `json:"-"`
+	Reputation         map[string]int   `json:"-"` // standing with each faction, -100..100
+	ProtectedUntil     int              `json:"-"` // security detail foils black ops through this turn
+	Pirate             bool             `json:"-"` // opted in to intercepting ships at their port
+	ConvoyID           string           `json:"-"` // convoy the flagship is travelling in, if any
+	Guild              *GuildMembership `json:"-"`
+	Insurance          *Insurance       `json:"-"`
+	Tax                *TaxLedger       `json:"-"` // income tax owed on this period's trading
+	TradeProgress      map[string]int   `json:"-"` // trade value toward the next reputation point
+	MarketBuys         buyLedger        `json:"-"` // goods bought at each market that may still be aboard
+	ReservedFunds      int              `json:"-"` // cash held against open auction bids
+	Bankrupt           bool             `json:"-"`
+	FacilityInvestment int              `json:"-"`
+	UpgradeInvestment  int              `json:"-"`
 	// Recent actions (last 10)
 	ActionHistory []ActionLog `json:"-"`
 	// Bot-specific memory (only used by bots)
@@ -128,6 +132,13 @@ type BlackOpsContract struct {
 	TriggerTurn int
 	Applied     map[PlayerID]bool
 	Price       int
+	Exposed     bool // a target's investigation traced it back to the instigator
+	// Targeted covert actions bought from a fixer; Kind is empty for shady contracts
+	Kind      string
+	Target    PlayerID
+	Planet    string // rumour
+	Good      string // rumour
+	Direction string // rumour: "up" or "down"
 }
 
 func (gs *GameServer) logAction(room *Room, p *Player, text string) {
@@ -150,18 +161,37 @@ type Room struct {
 	Planets         map[string]*Planet            `json:"planets"`
 	Persist         map[PlayerID]*PersistedPlayer `json:"-"`
 	mu              sync.Mutex
-	readyCh         chan struct{}         // signal to end turn early when all humans are ready
-	closeCh         chan struct{}         // signal to stop the ticker when room is closed
-	Private         bool                  `json:"-"`
-	CreatorID       PlayerID              `json:"-"`
-	Paused          bool                  `json:"-"`
-	stateCh         chan struct{}         `json:"-"`
-	TurnEndsAt      time.Time             `json:"-"`
-	News            []NewsItem            `json:"-"`
-	PlanetOrder     []string              `json:"-"`
-	PlanetPositions map[string][2]float64 `json:"-"`
-	ActiveAuction   *FederationAuction    `json:"-"`
-	PendingBlackOps []*BlackOpsContract   `json:"-"`
+	readyCh         chan struct{}                // signal to end turn early when all humans are ready
+	closeCh         chan struct{}                // signal to stop the ticker when room is closed
+	Private         bool                         `json:"-"`
+	CreatorID       PlayerID                     `json:"-"`
+	Paused          bool                         `json:"-"`
+	stateCh         chan struct{}                `json:"-"`
+	TurnEndsAt      time.Time                    `json:"-"`
+	News            []NewsItem                   `json:"-"`
+	PlanetOrder     []string                     `json:"-"`
+	PlanetPositions map[string][2]float64        `json:"-"`
+	Lanes           []*Lane                      `json:"-"` // hyperlane graph ships travel along
+	Galaxy          GalaxySettings               `json:"-"`
+	UnitScale       float64                      `json:"-"` // travel units per normalized distance
+	PriceRanges     map[string][2]int            `json:"-"` // nil uses defaultPriceRanges
+	Orbits          map[string]Orbit             `json:"-"` // nil keeps PlanetPositions fixed
+	DisabledEvents  map[string]bool              `json:"-"` // event cards switched off for this room
+	EventLog        []EventLogEntry              `json:"-"`
+	ScheduledEvents []ScheduledEvent             `json:"-"` // follow-up event cards due on later turns
+	ActiveAuction   *FederationAuction           `json:"-"`
+	Auctions        []*FederationAuction         `json:"-"` // player-run listings
+	MissionBoard    map[string][]*Mission        `json:"-"` // open contracts by origin planet
+	PassengerDemand map[string][]*PassengerGroup `json:"-"` // travellers waiting by origin planet
+	Convoys         []*Convoy                    `json:"-"`
+	Encounters      []*Encounter                 `json:"-"` // pirate interceptions awaiting a response
+	Incidents       []*BlackOpsIncident          `json:"-"` // black ops setbacks targets can investigate
+	PendingBlackOps []*BlackOpsContract          `json:"-"`
+	FixerPlanets    []string                     `json:"-"` // where fixers sell covert actions
+	BankPlanets     []string                     `json:"-"`
+	GuildHalls      []string                     `json:"-"` // where captains can join the Traders Guild
+	TaxBrackets     []TaxBracket                 `json:"-"` // nil uses defaultTaxBrackets
+	TaxInterval     int                          `json:"-"` // turns between assessments, 0 for the default
 }
 
 // ModalItem represents a queued modal to show to a specific player
@@ -178,11 +208,24 @@ type ModalItem struct {
 	SpeedBonus        int    `json:"speedBonus,omitempty"`
 	FuelCapacityBonus int    `json:"fuelCapacityBonus,omitempty"`
 	// Auction-specific fields
-	AuctionID    string `json:"auctionId,omitempty"`
-	FacilityType string `json:"facilityType,omitempty"`
-	Planet       string `json:"planet,omitempty"`
-	UsageCharge  int    `json:"usageCharge,omitempty"`
-	SuggestedBid int    `json:"suggestedBid,omitempty"`
+	AuctionID     string `json:"auctionId,omitempty"`
+	FacilityType  string `json:"facilityType,omitempty"`
+	Planet        string `json:"planet,omitempty"`
+	UsageCharge   int    `json:"usageCharge,omitempty"`
+	SuggestedBid  int    `json:"suggestedBid,omitempty"`
+	AuctionFormat string `json:"auctionFormat,omitempty"`
+	// Facility sale offers
+	FacilityID string   `json:"facilityId,omitempty"`
+	SellerID   PlayerID `json:"sellerId,omitempty"`
+	// Pirate encounters
+	EncounterID string `json:"encounterId,omitempty"`
+	// Convoy invitations
+	InviterID   PlayerID `json:"inviterId,omitempty"`
+	Destination string   `json:"destination,omitempty"`
+	// Multi-choice modals are answered with a choiceId instead of accept
+	Choices     []ModalChoice `json:"choices,omitempty"`
+	EventID     string        `json:"eventId,omitempty"`
+	ExpiresTurn int           `json:"expiresTurn,omitempty"` // choices lapse after this turn
 }
 
 // NewsItem represents a temporary room-wide event affecting a planet's prices/production
@@ -193,6 +236,9 @@ type NewsItem struct {
 	ProdDelta      map[string]int `json:"prodDelta,omitempty"`
 	TurnsRemaining int            `json:"turnsRemaining"`
 	FuelPriceDelta int            `json:"-"`
+	PirateRisk     int            `json:"-"` // extra per-mille encounter chance on legs touching Planet
+	ClosedLane     string         `json:"-"` // hyperlane shut while the item runs
+	StartsIn       int            `json:"-"` // turns before the item takes effect and goes public; guild members see it early
 }
 
 type singleplayerSavePayload struct {
@@ -267,11 +313,32 @@ type singleplayerPlayerSnapshot struct {
 	UpgradeValue       int                        `json:"upgradeValue"`
 	CargoValue         int                        `json:"cargoValue"`
 	MarketMemory       map[string]*MarketSnapshot `json:"marketMemory"`
+	FleetValue         int                        `json:"fleetValue"`
+	Fleet              []singleplayerShipSnapshot `json:"fleet"`
+}
+
+type singleplayerShipSnapshot struct {
+	ID                string         `json:"id"`
+	Name              string         `json:"name"`
+	CurrentPlanet     string         `json:"currentPlanet"`
+	DestinationPlanet string         `json:"destinationPlanet"`
+	Inventory         map[string]int `json:"inventory"`
+	InventoryAvgCost  map[string]int `json:"inventoryAvgCost"`
+	Fuel              int            `json:"fuel"`
+	InTransit         bool           `json:"inTransit"`
+	TransitFrom       string         `json:"transitFrom"`
+	TransitRemaining  int            `json:"transitRemaining"`
+	TransitTotal      int            `json:"transitTotal"`
+	Capacity          int            `json:"capacity"`
+	FuelCapacity      int            `json:"fuelCapacity"`
+	SpeedPerTurn      int            `json:"speedPerTurn"`
 }
 type Planet struct {
-	Name   string         `json:"name"`
-	Goods  map[string]int `json:"goods"`
-	Prices map[string]int `json:"prices"`
+	Name    string         `json:"name"`
+	Profile string         `json:"profile,omitempty"` // resource profile of a generated system
+	Faction string         `json:"-"`                 // holding faction of a generated system; classic maps go by name
+	Goods   map[string]int `json:"goods"`
+	Prices  map[string]int `json:"prices"`
 	// Prod is per-turn production for goods at this location (server-only)
 	// Prod is per-turn production for goods at this location (server-only)
 	Prod map[string]int `json:"-"`
@@ -296,6 +363,14 @@ type Facility struct {
 	UsageCharge   int      `json:"usageCharge"`  // cost per turn for non-owners
 	AccruedMoney  int      `json:"accruedMoney"` // money waiting to be collected by owner
 	PurchasePrice int      `json:"purchasePrice"`
+	// Cap table out of facilityShareCount and the open share order book
+	Shares    map[PlayerID]int `json:"shares,omitempty"`
+	ShareAsks []*ShareAsk      `json:"shareAsks,omitempty"`
+	// Production line (Refinery/Factory only) and the owner's warehouse at this facility
+	Recipe  string         `json:"recipe,omitempty"`
+	Storage map[string]int `json:"storage,omitempty"`
+	// Earliest turn a Federation-held (reclaimed) facility goes back up for auction
+	NextAuctionTurn int `json:"-"`
 }
 
 // FederationAuction represents an active facility auction
@@ -307,6 +382,27 @@ type FederationAuction struct {
 	SuggestedBid int              `json:"suggestedBid"`
 	Bids         map[PlayerID]int `json:"bids"`
 	TurnsLeft    int              `json:"turnsLeft"`
+	FacilityID   string           `json:"facilityId,omitempty"` // set when re-auctioning a reclaimed facility
+	Format       string           `json:"format"`
+	ReservePrice int              `json:"reservePrice"`           // minimum bid; Dutch floor; Vickrey price with a single bid
+	CurrentPrice int              `json:"currentPrice,omitempty"` // English high bid or Dutch asking price
+	MinIncrement int              `json:"minIncrement,omitempty"` // English raise step or Dutch price drop per turn
+	HighBidder   PlayerID         `json:"highBidder,omitempty"`
+	// Server-only bookkeeping
+	BidSeq    map[PlayerID]int `json:"-"` // order bids were placed, for tie-breaking
+	Reserved  map[PlayerID]int `json:"-"` // funds each bidder has tied up
+	BotLimits map[PlayerID]int `json:"-"` // private bot valuations
+	nextSeq   int
+	// Player-run listings (an empty Seller means a Federation facility auction)
+	Seller      PlayerID `json:"seller,omitempty"`
+	SellerName  string   `json:"sellerName,omitempty"`
+	LotKind     string   `json:"lotKind,omitempty"` // "facility", "goods" or "upgrade"
+	Good        string   `json:"good,omitempty"`
+	Quantity    int      `json:"quantity,omitempty"` // goods units or upgrade units
+	UpgradeKind string   `json:"upgradeKind,omitempty"`
+	ShipID      string   `json:"-"` // ship the escrowed goods came from
+	LotAvgCost  int      `json:"-"`
+	LotBook     int      `json:"-"` // UpgradeInvestment taken into escrow with an upgrade lot
 }
 
 // PersistedPlayer stores the subset of player state we want to keep per-room for rejoin
@@ -325,6 +421,7 @@ type PersistedPlayer struct {
 	TransitFrom        string
 	TransitRemaining   int
 	TransitTotal       int
+	TransitPath        []string
 	CapacityBonus      int
 	SpeedBonus         int
 	FuelCapacityBonus  int
@@ -332,6 +429,24 @@ type PersistedPlayer struct {
 	FacilityInvestment int
 	UpgradeInvestment  int
 	MarketMemory       map[string]*MarketSnapshot
+	Fleet              []*Ship
+	FleetInvestment    int
+	Loan               *Loan
+	Missions           []*Mission
+	Passengers         []*PassengerGroup
+	Reputation         map[string]int
+	TradeProgress      map[string]int
+	MarketBuys         buyLedger
+	ProtectedUntil     int
+	Pirate             bool
+	Weapons            int
+	Shields            int
+	Escort             bool
+	Waypoints          []string
+	Guild              *GuildMembership
+	Insurance          *Insurance
+	Tax                *TaxLedger
+	LeftTurn           int // turn the player left; facilities are abandoned after facilityAbandonTurns
 }
 
 type GameServer struct {
@@ -397,16 +512,19 @@ func (gs *GameServer) HandleWS(w http.ResponseWriter, r *http.Request, cognitoCo
 
 	// Create player with authenticated user info
 	p := &Player{
-		ID:                PlayerID(userClaims.Sub), // Use Cognito user ID
-		Name:              userClaims.Name,
-		Money:             1000,
-		CurrentPlanet:     "Earth",
-		DestinationPlanet: "",
-		Inventory:         map[string]int{},
-		InventoryAvgCost:  map[string]int{},
-		Fuel:              fuelCapacity,
-		PriceMemory:       make(map[string]*PriceMemory),
-		MarketMemory:      make(map[string]*MarketSnapshot),
+		ID:    PlayerID(userClaims.Sub), // Use Cognito user ID
+		Name:  userClaims.Name,
+		Money: 1000,
+		Ship: Ship{
+			ID:                flagshipID,
+			CurrentPlanet:     "Earth",
+			DestinationPlanet: "",
+			Inventory:         map[string]int{},
+			InventoryAvgCost:  map[string]int{},
+			Fuel:              fuelCapacity,
+		},
+		PriceMemory:  make(map[string]*PriceMemory),
+		MarketMemory: make(map[string]*MarketSnapshot),
 	}
 	p.conn = conn
 	go gs.readLoop(p)
@@ -453,8 +571,9 @@ func (gs *GameServer) HandleListRooms(w http.ResponseWriter, r *http.Request) {
 
 func (gs *GameServer) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 	var data struct {
-		Name         string `json:"name"`
-		Singleplayer bool   `json:"singleplayer"`
+		Name         string         `json:"name"`
+		Singleplayer bool           `json:"singleplayer"`
+		Galaxy       GalaxySettings `json:"galaxy"` // size 0 plays the classic solar system
 	}
 	if r.Body != nil {
 		if err := json.NewDecoder(r.Body).Decode(&data); err != nil && err != io.EOF {
@@ -462,7 +581,7 @@ func (gs *GameServer) HandleCreateRoom(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	data.Name = sanitizeAlphanumeric(data.Name)
-	room := gs.createRoom(data.Name, "", data.Singleplayer)
+	room := gs.createRoom(data.Name, "", data.Singleplayer, data.Galaxy)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"id": room.ID, "name": room.Name})
 }
@@ -498,6 +617,7 @@ func (gs *GameServer) readLoop(p *Player) {
 					TransitFrom:        p.TransitFrom,
 					TransitRemaining:   p.TransitRemaining,
 					TransitTotal:       p.TransitTotal,
+					TransitPath:        append([]string(nil), p.TransitPath...),
 					CapacityBonus:      p.CapacityBonus,
 					SpeedBonus:         p.SpeedBonus,
 					FuelCapacityBonus:  p.FuelCapacityBonus,
@@ -505,7 +625,26 @@ func (gs *GameServer) readLoop(p *Player) {
 					FacilityInvestment: p.FacilityInvestment,
 					UpgradeInvestment:  p.UpgradeInvestment,
 					MarketMemory:       cloneMarketMemory(p.MarketMemory),
+					Fleet:              cloneFleet(p.Fleet),
+					FleetInvestment:    p.FleetInvestment,
+					Loan:               cloneLoan(p.Loan),
+					Missions:           cloneMissions(p.Missions),
+					Passengers:         clonePassengers(p.Passengers),
+					Reputation:         cloneReputation(p.Reputation),
+					TradeProgress:      cloneReputation(p.TradeProgress),
+					MarketBuys:         cloneBuyLedger(p.MarketBuys),
+					ProtectedUntil:     p.ProtectedUntil,
+					Pirate:             p.Pirate,
+					Weapons:            p.Weapons,
+					Shields:            p.Shields,
+					Escort:             p.Escort,
+					Waypoints:          append([]string(nil), p.Waypoints...),
+					Guild:              cloneGuild(p.Guild),
+					Insurance:          cloneInsurance(p.Insurance),
+					Tax:                cloneTaxLedger(p.Tax),
+					LeftTurn:           room.Turn,
 				}
+				releasePlayerBids(room, p)
 				delete(room.Players, p.ID)
 				p.roomID = ""
 
@@ -588,8 +727,9 @@ func (gs *GameServer) readLoop(p *Player) {
 			gs.sendLobbyState(p)
 		case "createRoom":
 			var data struct {
-				Name         string `json:"name"`
-				Singleplayer bool   `json:"singleplayer"`
+				Name         string         `json:"name"`
+				Singleplayer bool           `json:"singleplayer"`
+				Galaxy       GalaxySettings `json:"galaxy"` // size 0 plays the classic solar system
 			}
 			if len(msg.Payload) > 0 {
 				if err := json.Unmarshal(msg.Payload, &data); err != nil {
@@ -597,7 +737,7 @@ func (gs *GameServer) readLoop(p *Player) {
 				}
 			}
 			data.Name = sanitizeAlphanumeric(data.Name)
-			room := gs.createRoom(data.Name, p.ID, data.Singleplayer)
+			room := gs.createRoom(data.Name, p.ID, data.Singleplayer, data.Galaxy)
 			gs.joinRoom(p, room.ID)
 		case "joinRoom":
 			var data struct {
@@ -625,23 +765,27 @@ func (gs *GameServer) readLoop(p *Player) {
 				gs.sendRoomState(room, p)
 			}
 		case "respondModal":
-			// payload: { id, accept }
+			// payload: { id, accept, choiceId }
 			var data struct {
-				ID     string `json:"id"`
-				Accept bool   `json:"accept"`
+				ID       string `json:"id"`
+				Accept   bool   `json:"accept"`
+				ChoiceID string `json:"choiceId"`
 			}
 			json.Unmarshal(msg.Payload, &data)
 			if room := gs.getRoom(p.roomID); room != nil {
 				room.mu.Lock()
-				if len(p.Modals) > 0 && p.Modals[0].ID == data.ID {
+				if len(p.Modals) > 0 && p.Modals[0].ID == data.ID && len(p.Modals[0].Choices) > 0 {
+					gs.resolveModalChoice(room, p, data.ChoiceID, data.Accept)
+				} else if len(p.Modals) > 0 && p.Modals[0].ID == data.ID {
 					m := p.Modals[0]
 					// Remove modal
 					p.Modals = append([]ModalItem(nil), p.Modals[1:]...)
 					if m.Kind == "upgrade-offer" && data.Accept {
-						if p.Money >= m.Price {
+						if spendableFunds(p) >= m.Price {
 							p.Money -= m.Price
 							p.CapacityBonus += m.CapacityBonus
 							p.UpgradeInvestment += m.Price
+							recordDeduction(p, deductUpgrades, m.Price)
 							// Confirm
 							gs.enqueueModal(p, "Upgrade Installed", "Your cargo capacity increased by "+strconv.Itoa(m.CapacityBonus)+" to "+strconv.Itoa(shipCapacity+p.CapacityBonus)+".")
 							gs.logAction(room, p, fmt.Sprintf("Purchased cargo upgrade +%d for $%d", m.CapacityBonus, m.Price))
@@ -651,10 +795,11 @@ func (gs *GameServer) readLoop(p *Player) {
 					}
 					if m.Kind == "speed-offer" && data.Accept {
 						price := m.PricePerUnit * m.Units
-						if p.Money >= price {
+						if spendableFunds(p) >= price {
 							p.Money -= price
 							p.SpeedBonus += m.Units
 							p.UpgradeInvestment += price
+							recordDeduction(p, deductUpgrades, price)
 							gs.enqueueModal(p, "Engine Upgrade Installed", "Your ship speed increased by "+strconv.Itoa(m.Units)+" units/turn.")
 							gs.logAction(room, p, fmt.Sprintf("Purchased engine upgrade +%d for $%d", m.Units, price))
 						} else {
@@ -663,16 +808,23 @@ func (gs *GameServer) readLoop(p *Player) {
 					}
 					if m.Kind == "fuelcap-offer" && data.Accept {
 						price := m.PricePerUnit * m.Units
-						if p.Money >= price {
+						if spendableFunds(p) >= price {
 							p.Money -= price
 							p.FuelCapacityBonus += m.Units
 							p.UpgradeInvestment += price
+							recordDeduction(p, deductUpgrades, price)
 							gs.enqueueModal(p, "Fuel Tank Expanded", "Your fuel capacity increased by "+strconv.Itoa(m.Units)+" to "+strconv.Itoa(fuelCapacity+p.FuelCapacityBonus)+".")
 							gs.logAction(room, p, fmt.Sprintf("Purchased fuel tank +%d for $%d", m.Units, price))
 						} else {
 							gs.enqueueModal(p, "Insufficient Funds", "You don't have enough credits for this upgrade.")
 						}
 					}
+					if m.Kind == "facility-offer" {
+						gs.resolveFacilityOffer(room, p, m, data.Accept)
+					}
+					if m.Kind == "convoy-invite" {
+						gs.resolveConvoyInvite(room, p, m, data.Accept)
+					}
 					if m.Kind == "shady-contract" {
 						if data.Accept {
 							price := m.Price
@@ -693,6 +845,7 @@ func (gs *GameServer) readLoop(p *Player) {
 									}
 									gs.enqueueModal(p, "Federation Sting!", fmt.Sprintf("Federation agents confiscate your %d-credit payment, fine you an additional %d credits, and impound your cargo.", price, fine))
 									gs.logAction(room, p, fmt.Sprintf("Federation sting seized shady contract funds ($%d) and cargo", price+fine))
+									gs.adjustAllReputation(room, p, -reputationStingLoss, "exposed in a Federation sting")
 								} else {
 									contract := &BlackOpsContract{
 										ID:          randID(),
@@ -725,30 +878,43 @@ func (gs *GameServer) readLoop(p *Player) {
 		case "selectPlanet":
 			var data struct {
 				Planet string `json:"planet"`
+				ShipID string `json:"shipId"`
 			}
 			json.Unmarshal(msg.Payload, &data)
 			if room := gs.getRoom(p.roomID); room != nil {
 				room.mu.Lock()
 				allow := true
-				if p.Bankrupt {
+				ship := p.shipByID(data.ShipID)
+				if p.Bankrupt || ship == nil {
 					allow = false
 				}
-				if p.InTransit {
+				if ship != nil && ship.Route != nil {
+					allow = false
+					gs.enqueueModal(p, "Captain in Command", "The "+ship.Name+" is running an automated route. Dismiss the captain to steer it yourself.")
+				} else if ship != nil && ship.InTransit {
 					allow = false
-					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(p.DestinationPlanet, "your destination")+".")
+					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(ship.DestinationPlanet, "your destination")+".")
 				}
-				if len(room.PlanetPositions) > 0 && data.Planet != "" && data.Planet != p.CurrentPlanet {
-					cost := distanceUnits(room, p.CurrentPlanet, data.Planet)
-					if cost > p.Fuel {
+				if ship != nil && len(room.PlanetPositions) > 0 && data.Planet != "" && data.Planet != ship.CurrentPlanet {
+					cost := departureUnits(room, ship.CurrentPlanet, data.Planet)
+					if cost > ship.Fuel {
 						allow = false
 						gs.enqueueModal(p, "Insufficient Fuel", "You don't have enough fuel to reach "+data.Planet+".")
 					}
 				}
 				if allow {
-					p.DestinationPlanet = data.Planet
-					if data.Planet != "" && data.Planet != p.CurrentPlanet {
-						units := distanceUnits(room, p.CurrentPlanet, data.Planet)
-						gs.logAction(room, p, fmt.Sprintf("Traveling to %s (%d units)", data.Planet, units))
+					ship.DestinationPlanet = data.Planet
+					ship.Waypoints = nil
+					if c := playerConvoy(room, p); c != nil && p.isFlagship(ship) && data.Planet != c.Destination {
+						gs.leaveConvoy(room, p, "changed course")
+					}
+					if data.Planet != "" && data.Planet != ship.CurrentPlanet {
+						units := departureUnits(room, ship.CurrentPlanet, data.Planet)
+						if p.isFlagship(ship) {
+							gs.logAction(room, p, fmt.Sprintf("Traveling to %s (%d units)", data.Planet, units))
+						} else {
+							gs.logAction(room, p, fmt.Sprintf("%s traveling to %s (%d units)", ship.Name, data.Planet, units))
+						}
 					}
 				}
 				room.mu.Unlock()
@@ -762,37 +928,41 @@ func (gs *GameServer) readLoop(p *Player) {
 			var data struct {
 				Good   string `json:"good"`
 				Amount int    `json:"amount"`
+				ShipID string `json:"shipId"`
 			}
 			json.Unmarshal(msg.Payload, &data)
 			if room := gs.getRoom(p.roomID); room != nil {
-				if p.Bankrupt {
+				ship := p.shipByID(data.ShipID)
+				if p.Bankrupt || ship == nil {
 					gs.sendRoomState(room, p)
 					break
 				}
-				if p.InTransit {
-					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(p.DestinationPlanet, "your destination")+".")
+				if ship.InTransit {
+					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(ship.DestinationPlanet, "your destination")+".")
 					gs.sendRoomState(room, p)
 					break
 				}
-				gs.handleBuy(room, p, data.Good, data.Amount)
+				gs.handleBuy(room, p, ship, data.Good, data.Amount)
 			}
 		case "sell":
 			var data struct {
 				Good   string `json:"good"`
 				Amount int    `json:"amount"`
+				ShipID string `json:"shipId"`
 			}
 			json.Unmarshal(msg.Payload, &data)
 			if room := gs.getRoom(p.roomID); room != nil {
-				if p.Bankrupt {
+				ship := p.shipByID(data.ShipID)
+				if p.Bankrupt || ship == nil {
 					gs.sendRoomState(room, p)
 					break
 				}
-				if p.InTransit {
-					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(p.DestinationPlanet, "your destination")+".")
+				if ship.InTransit {
+					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(ship.DestinationPlanet, "your destination")+".")
 					gs.sendRoomState(room, p)
 					break
 				}
-				gs.handleSell(room, p, data.Good, data.Amount)
+				gs.handleSell(room, p, ship, data.Good, data.Amount)
 			}
 		case "auctionBid":
 			var data struct {
@@ -906,6 +1076,109 @@ func (gs *GameServer) readLoop(p *Player) {
 				gs.exitRoom(p)
 			}
 		case "refuel":
+			var data struct {
+				Amount int    `json:"amount"`
+				ShipID string `json:"shipId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				ship := p.shipByID(data.ShipID)
+				if p.Bankrupt || ship == nil {
+					gs.sendRoomState(room, p)
+					break
+				}
+				if ship.InTransit {
+					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(ship.DestinationPlanet, "your destination")+".")
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleRefuel(room, p, ship, data.Amount)
+			}
+		case "setRoute":
+			// payload: { shipId, stops: [{ planet, buy, sell, refuel }] }
+			var data struct {
+				ShipID string      `json:"shipId"`
+				Stops  []RouteStop `json:"stops"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleSetRoute(room, p, data.ShipID, data.Stops)
+			}
+		case "clearRoute":
+			var data struct {
+				ShipID string `json:"shipId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				gs.handleClearRoute(room, p, data.ShipID)
+			}
+		case "routeReport":
+			var data struct {
+				ShipID string `json:"shipId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			room := gs.getRoom(p.roomID)
+			if room == nil {
+				break
+			}
+			room.mu.Lock()
+			var payload interface{}
+			if ship := p.shipByID(data.ShipID); ship != nil && ship.Route != nil {
+				payload = routeReportPayload(room, ship)
+			}
+			room.mu.Unlock()
+			if payload != nil && p.conn != nil {
+				p.writeMu.Lock()
+				p.conn.WriteJSON(WSOut{Type: "routeReport", Payload: payload})
+				p.writeMu.Unlock()
+			}
+		case "planRoute":
+			// payload: { shipId, destination, mode: "cheapest"|"fastest", apply }
+			var data struct {
+				ShipID      string `json:"shipId"`
+				Destination string `json:"destination"`
+				Mode        string `json:"mode"`
+				Apply       bool   `json:"apply"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			room := gs.getRoom(p.roomID)
+			if room == nil {
+				break
+			}
+			if p.Bankrupt {
+				gs.sendRoomState(room, p)
+				break
+			}
+			room.mu.Lock()
+			payload := gs.planRouteFor(room, p, data.ShipID, data.Destination, data.Mode, data.Apply)
+			room.mu.Unlock()
+			if payload != nil && p.conn != nil {
+				p.writeMu.Lock()
+				p.conn.WriteJSON(WSOut{Type: "routePlan", Payload: payload})
+				p.writeMu.Unlock()
+			}
+			if data.Apply {
+				gs.sendRoomState(room, nil)
+			}
+		case "takeLoan":
+			// payload: { amount }
+			var data struct {
+				Amount int `json:"amount"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleTakeLoan(room, p, data.Amount)
+			}
+		case "repayLoan":
+			// payload: { amount } (0 repays as much as possible)
 			var data struct {
 				Amount int `json:"amount"`
 			}
@@ -915,12 +1188,345 @@ func (gs *GameServer) readLoop(p *Player) {
 					gs.sendRoomState(room, p)
 					break
 				}
+				gs.handleRepayLoan(room, p, data.Amount)
+			}
+		case "buyShip":
+			// payload: { name }
+			var data struct {
+				Name string `json:"name"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleBuyShip(room, p, data.Name)
+			}
+		case "listShares":
+			// payload: { facilityId, shares, price } (price per share)
+			var data struct {
+				FacilityID string `json:"facilityId"`
+				Shares     int    `json:"shares"`
+				Price      int    `json:"price"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleListShares(room, p, data.FacilityID, data.Shares, data.Price)
+			}
+		case "buyShares":
+			// payload: { facilityId, shares }
+			var data struct {
+				FacilityID string `json:"facilityId"`
+				Shares     int    `json:"shares"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleBuyShares(room, p, data.FacilityID, data.Shares)
+			}
+		case "setFacilityCharge":
+			// payload: { facilityId, charge }
+			var data struct {
+				FacilityID string `json:"facilityId"`
+				Charge     int    `json:"charge"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleSetFacilityCharge(room, p, data.FacilityID, data.Charge)
+			}
+		case "setRecipe":
+			// payload: { facilityId, recipeId } (empty recipeId stops production)
+			var data struct {
+				FacilityID string `json:"facilityId"`
+				RecipeID   string `json:"recipeId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleSetRecipe(room, p, data.FacilityID, data.RecipeID)
+			}
+		case "facilityStorage":
+			// payload: { facilityId, shipId?, good, amount } (positive deposits, negative withdraws)
+			var data struct {
+				FacilityID string `json:"facilityId"`
+				ShipID     string `json:"shipId"`
+				Good       string `json:"good"`
+				Amount     int    `json:"amount"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleFacilityStorage(room, p, data.FacilityID, data.ShipID, data.Good, data.Amount)
+			}
+		case "sellFacility":
+			// payload: { facilityId, buyerId?, price? } (no buyer sells to the Federation)
+			var data struct {
+				FacilityID string `json:"facilityId"`
+				BuyerID    string `json:"buyerId"`
+				Price      int    `json:"price"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleSellFacility(room, p, data.FacilityID, PlayerID(data.BuyerID), data.Price)
+			}
+		case "listAuction":
+			// payload: { kind: goods|upgrade|facility, shipId?, good?, quantity?, upgrade?, facilityId?, reservePrice, turns, format? }
+			var data listingRequest
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleListAuction(room, p, data)
+			}
+		case "cancelAuction":
+			// payload: { auctionId }
+			var data struct {
+				AuctionID string `json:"auctionId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				gs.handleCancelAuction(room, p, data.AuctionID)
+			}
+		case "acceptMission":
+			// payload: { missionId, shipId? }
+			var data struct {
+				MissionID string `json:"missionId"`
+				ShipID    string `json:"shipId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleAcceptMission(room, p, data.MissionID, data.ShipID)
+			}
+		case "boardPassengers":
+			// payload: { groupId, shipId? }
+			var data struct {
+				GroupID string `json:"groupId"`
+				ShipID  string `json:"shipId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleBoardPassengers(room, p, data.GroupID, data.ShipID)
+			}
+		case "investigate":
+			// payload: { incidentId, amount }
+			var data struct {
+				IncidentID string `json:"incidentId"`
+				Amount     int    `json:"amount"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleInvestigate(room, p, data.IncidentID, data.Amount)
+			}
+		case "buyProtection":
+			// payload: { turns }
+			var data struct {
+				Turns int `json:"turns"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleBuyProtection(room, p, data.Turns)
+			}
+		case "hireCovertAction":
+			// payload: { action, targetId?, planet?, good?, direction? }
+			var data struct {
+				Action    string `json:"action"`
+				TargetID  string `json:"targetId"`
+				Planet    string `json:"planet"`
+				Good      string `json:"good"`
+				Direction string `json:"direction"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleHireCovertAction(room, p, data.Action, PlayerID(data.TargetID), data.Planet, data.Good, data.Direction)
+			}
+		case "resolveEncounter":
+			// payload: { encounterId, action: fight|flee|tribute }
+			var data struct {
+				EncounterID string `json:"encounterId"`
+				Action      string `json:"action"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				gs.handleResolveEncounter(room, p, data.EncounterID, data.Action)
+			}
+		case "buyArmament":
+			// payload: { shipId?, kind: weapons|shields }
+			var data struct {
+				ShipID string `json:"shipId"`
+				Kind   string `json:"kind"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleBuyArmament(room, p, data.ShipID, data.Kind)
+			}
+		case "setPiracy":
+			// payload: { enabled }
+			var data struct {
+				Enabled bool `json:"enabled"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleSetPiracy(room, p, data.Enabled)
+			}
+		case "setEscort":
+			// payload: { shipId?, enabled }
+			var data struct {
+				ShipID  string `json:"shipId"`
+				Enabled bool   `json:"enabled"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleSetEscort(room, p, data.ShipID, data.Enabled)
+			}
+		case "inviteConvoy":
+			// payload: { targetId }
+			var data struct {
+				TargetID string `json:"targetId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleInviteConvoy(room, p, PlayerID(data.TargetID))
+			}
+		case "leaveConvoy":
+			if room := gs.getRoom(p.roomID); room != nil {
+				room.mu.Lock()
 				if p.InTransit {
-					gs.enqueueModal(p, "In Transit", "You are still in transit towards "+defaultStr(p.DestinationPlanet, "your destination")+".")
+					gs.enqueueModal(p, "In Transit", "You can only leave a convoy in port.")
+				} else {
+					gs.leaveConvoy(room, p, "left")
+				}
+				room.mu.Unlock()
+				gs.sendRoomState(room, nil)
+			}
+		case "setEventEnabled":
+			// payload: { eventId, enabled }
+			var data struct {
+				EventID string `json:"eventId"`
+				Enabled bool   `json:"enabled"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				gs.handleSetEventEnabled(room, p, data.EventID, data.Enabled)
+			}
+		case "setTaxBrackets":
+			// payload: { brackets: [{ from, ratePct }], interval }
+			var data struct {
+				Brackets []TaxBracket `json:"brackets"`
+				Interval int          `json:"interval"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				gs.handleSetTaxBrackets(room, p, data.Brackets, data.Interval)
+			}
+		case "joinGuild":
+			// payload: {}
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleJoinGuild(room, p)
+			}
+		case "leaveGuild":
+			// payload: {}
+			if room := gs.getRoom(p.roomID); room != nil {
+				gs.handleLeaveGuild(room, p)
+			}
+		case "setInsurance":
+			// payload: { policy, enabled }
+			var data struct {
+				Policy  string `json:"policy"`
+				Enabled bool   `json:"enabled"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
 					gs.sendRoomState(room, p)
 					break
 				}
-				gs.handleRefuel(room, p, data.Amount)
+				gs.handleSetInsurance(room, p, data.Policy, data.Enabled)
+			}
+		case "abandonMission":
+			// payload: { missionId }
+			var data struct {
+				MissionID string `json:"missionId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				if p.Bankrupt {
+					gs.sendRoomState(room, p)
+					break
+				}
+				gs.handleAbandonMission(room, p, data.MissionID)
+			}
+		case "cancelShares":
+			// payload: { facilityId }
+			var data struct {
+				FacilityID string `json:"facilityId"`
+			}
+			json.Unmarshal(msg.Payload, &data)
+			if room := gs.getRoom(p.roomID); room != nil {
+				gs.handleCancelShares(room, p, data.FacilityID)
 			}
 		}
 	}
@@ -964,7 +1570,7 @@ func (gs *GameServer) sendLobbyState(p *Player) {
 	}
 }
 
-func (gs *GameServer) createRoom(name string, creator PlayerID, private bool) *Room {
+func (gs *GameServer) createRoom(name string, creator PlayerID, private bool, galaxy GalaxySettings) *Room {
 	name = sanitizeAlphanumeric(name)
 	if name == "" {
 		name = "Room " + randID()[0:4]
@@ -986,15 +1592,28 @@ func (gs *GameServer) createRoom(name string, creator PlayerID, private bool) *R
 		}(),
 		Paused:  false,
 		stateCh: make(chan struct{}, 1),
+		Galaxy:  normalizeGalaxy(galaxy),
 	}
+	room.UnitScale = galaxyUnitScale(room.Galaxy)
 	// Pre-randomize planet order and positions so the map is ready before game start
-	names := planetNames(room.Planets)
-	for i := range names {
-		j := rand.Intn(i + 1)
-		names[i], names[j] = names[j], names[i]
+	var names []string
+	if room.Galaxy.Size > 0 {
+		room.Planets, names, room.PlanetPositions, room.PriceRanges = generateGalaxy(room.Galaxy)
+	} else {
+		names = planetNames(room.Planets)
+		for i := range names {
+			j := rand.Intn(i + 1)
+			names[i], names[j] = names[j], names[i]
+		}
+		room.PlanetPositions = generatePlanetPositions(names)
 	}
 	room.PlanetOrder = names
-	room.PlanetPositions = generatePlanetPositions(names)
+	room.Orbits = generateOrbits(rand.New(rand.NewSource(room.Galaxy.Seed)), room.PlanetPositions)
+	advanceOrbits(room)
+	room.Lanes = generateLanes(rand.New(rand.NewSource(room.Galaxy.Seed)), names, room.PlanetPositions, room.unitScale())
+	room.BankPlanets = pickBankPlanets(names)
+	room.FixerPlanets = pickFixerPlanets(names)
+	room.GuildHalls = pickGuildHalls(names)
 	gs.roomsMu.Lock()
 	gs.rooms[room.ID] = room
 	gs.roomsMu.Unlock()
@@ -1037,6 +1656,7 @@ func (gs *GameServer) joinRoom(p *Player, roomID string) {
 				TransitFrom:        p.TransitFrom,
 				TransitRemaining:   p.TransitRemaining,
 				TransitTotal:       p.TransitTotal,
+				TransitPath:        append([]string(nil), p.TransitPath...),
 				CapacityBonus:      p.CapacityBonus,
 				SpeedBonus:         p.SpeedBonus,
 				FuelCapacityBonus:  p.FuelCapacityBonus,
@@ -1044,7 +1664,26 @@ func (gs *GameServer) joinRoom(p *Player, roomID string) {
 				FacilityInvestment: p.FacilityInvestment,
 				UpgradeInvestment:  p.UpgradeInvestment,
 				MarketMemory:       cloneMarketMemory(p.MarketMemory),
-			}
+				Fleet:              cloneFleet(p.Fleet),
+				FleetInvestment:    p.FleetInvestment,
+				Loan:               cloneLoan(p.Loan),
+				Missions:           cloneMissions(p.Missions),
+				Passengers:         clonePassengers(p.Passengers),
+				Reputation:         cloneReputation(p.Reputation),
+				TradeProgress:      cloneReputation(p.TradeProgress),
+				MarketBuys:         cloneBuyLedger(p.MarketBuys),
+				ProtectedUntil:     p.ProtectedUntil,
+				Pirate:             p.Pirate,
+				Weapons:            p.Weapons,
+				Shields:            p.Shields,
+				Escort:             p.Escort,
+				Waypoints:          append([]string(nil), p.Waypoints...),
+				Guild:              cloneGuild(p.Guild),
+				Insurance:          cloneInsurance(p.Insurance),
+				Tax:                cloneTaxLedger(p.Tax),
+				LeftTurn:           old.Turn,
+			}
+			releasePlayerBids(old, p)
 			delete(old.Players, p.ID)
 			old.mu.Unlock()
 			gs.broadcastRoom(old)
@@ -1079,12 +1718,30 @@ func (gs *GameServer) joinRoom(p *Player, roomID string) {
 		p.TransitFrom = snap.TransitFrom
 		p.TransitRemaining = snap.TransitRemaining
 		p.TransitTotal = snap.TransitTotal
+		p.TransitPath = snap.TransitPath
 		p.CapacityBonus = snap.CapacityBonus
 		p.SpeedBonus = snap.SpeedBonus
 		p.FuelCapacityBonus = snap.FuelCapacityBonus
 		p.Bankrupt = snap.Bankrupt
 		p.FacilityInvestment = snap.FacilityInvestment
 		p.UpgradeInvestment = snap.UpgradeInvestment
+		p.Fleet = cloneFleet(snap.Fleet)
+		p.FleetInvestment = snap.FleetInvestment
+		p.Loan = cloneLoan(snap.Loan)
+		p.Missions = cloneMissions(snap.Missions)
+		p.Passengers = clonePassengers(snap.Passengers)
+		p.Reputation = cloneReputation(snap.Reputation)
+		p.TradeProgress = cloneReputation(snap.TradeProgress)
+		p.MarketBuys = cloneBuyLedger(snap.MarketBuys)
+		p.ProtectedUntil = snap.ProtectedUntil
+		p.Pirate = snap.Pirate
+		p.Weapons = snap.Weapons
+		p.Shields = snap.Shields
+		p.Escort = snap.Escort
+		p.Waypoints = snap.Waypoints
+		p.Guild = cloneGuild(snap.Guild)
+		p.Insurance = cloneInsurance(snap.Insurance)
+		p.Tax = cloneTaxLedger(snap.Tax)
 		// restore per-room action history
 		p.ActionHistory = cloneActionHistory(snap.ActionHistory)
 		// Initialize price memory for bots (important for restored bots)
@@ -1114,9 +1771,28 @@ func (gs *GameServer) joinRoom(p *Player, roomID string) {
 		p.TransitFrom = ""
 		p.TransitRemaining = 0
 		p.TransitTotal = 0
+		p.TransitPath = nil
 		p.CapacityBonus = 0
 		p.SpeedBonus = 0
 		p.FuelCapacityBonus = 0
+		p.Fleet = nil
+		p.FleetInvestment = 0
+		p.Loan = nil
+		p.Missions = nil
+		p.Passengers = nil
+		p.Reputation = nil
+		p.TradeProgress = nil
+		p.MarketBuys = nil
+		p.ProtectedUntil = 0
+		p.Pirate = false
+		p.Weapons = 0
+		p.Shields = 0
+		p.Escort = false
+		p.Waypoints = nil
+		p.Guild = nil
+		p.Insurance = nil
+		p.Tax = nil
+		p.ConvoyID = ""
 		p.Bankrupt = false
 		// fresh room: clear per-room action history
 		p.ActionHistory = nil
@@ -1136,6 +1812,8 @@ func (gs *GameServer) joinRoom(p *Player, roomID string) {
 	if p.MarketMemory == nil {
 		p.MarketMemory = make(map[string]*MarketSnapshot)
 	}
+	p.Ship.ID = flagshipID
+	p.ReservedFunds = 0 // reservations were released on leaving
 	room.Players[p.ID] = p
 	if room.Private && room.CreatorID == p.ID {
 		room.Paused = false
@@ -1175,6 +1853,7 @@ func (gs *GameServer) exitRoom(p *Player) {
 		TransitFrom:        p.TransitFrom,
 		TransitRemaining:   p.TransitRemaining,
 		TransitTotal:       p.TransitTotal,
+		TransitPath:        append([]string(nil), p.TransitPath...),
 		CapacityBonus:      p.CapacityBonus,
 		SpeedBonus:         p.SpeedBonus,
 		FuelCapacityBonus:  p.FuelCapacityBonus,
@@ -1182,7 +1861,26 @@ func (gs *GameServer) exitRoom(p *Player) {
 		FacilityInvestment: p.FacilityInvestment,
 		UpgradeInvestment:  p.UpgradeInvestment,
 		MarketMemory:       cloneMarketMemory(p.MarketMemory),
-	}
+		Fleet:              cloneFleet(p.Fleet),
+		FleetInvestment:    p.FleetInvestment,
+		Loan:               cloneLoan(p.Loan),
+		Missions:           cloneMissions(p.Missions),
+		Passengers:         clonePassengers(p.Passengers),
+		Reputation:         cloneReputation(p.Reputation),
+		TradeProgress:      cloneReputation(p.TradeProgress),
+		MarketBuys:         cloneBuyLedger(p.MarketBuys),
+		ProtectedUntil:     p.ProtectedUntil,
+		Pirate:             p.Pirate,
+		Weapons:            p.Weapons,
+		Shields:            p.Shields,
+		Escort:             p.Escort,
+		Waypoints:          append([]string(nil), p.Waypoints...),
+		Guild:              cloneGuild(p.Guild),
+		Insurance:          cloneInsurance(p.Insurance),
+		Tax:                cloneTaxLedger(p.Tax),
+		LeftTurn:           room.Turn,
+	}
+	releasePlayerBids(room, p)
 	delete(room.Players, p.ID)
 	p.roomID = ""
 
@@ -1386,6 +2084,36 @@ func (gs *GameServer) restoreSingleplayerSnapshot(p *Player, roomID, encoded, ow
 		fuelBonus = 0
 	}
 	p.FuelCapacityBonus = fuelBonus
+	p.FleetInvestment = you.FleetValue
+	p.Fleet = nil
+	for _, fs := range you.Fleet {
+		if fs.ID == "" {
+			continue
+		}
+		ship := &Ship{
+			ID:                fs.ID,
+			Name:              fs.Name,
+			CurrentPlanet:     defaultStr(fs.CurrentPlanet, p.CurrentPlanet),
+			DestinationPlanet: fs.DestinationPlanet,
+			Inventory:         cloneIntMap(fs.Inventory),
+			InventoryAvgCost:  cloneIntMap(fs.InventoryAvgCost),
+			Fuel:              maxInt(0, fs.Fuel),
+			InTransit:         fs.InTransit,
+			TransitFrom:       fs.TransitFrom,
+			TransitRemaining:  fs.TransitRemaining,
+			TransitTotal:      fs.TransitTotal,
+			CapacityBonus:     maxInt(0, fs.Capacity-shipCapacity),
+			SpeedBonus:        maxInt(0, fs.SpeedPerTurn-baseShipSpeed),
+			FuelCapacityBonus: maxInt(0, fs.FuelCapacity-fuelCapacity),
+		}
+		if ship.Inventory == nil {
+			ship.Inventory = map[string]int{}
+		}
+		if ship.InventoryAvgCost == nil {
+			ship.InventoryAvgCost = map[string]int{}
+		}
+		p.Fleet = append(p.Fleet, ship)
+	}
 	p.Bankrupt = false
 	p.roomID = room.ID
 
@@ -1474,9 +2202,12 @@ func (gs *GameServer) startGame(roomID string) {
 			}
 			if len(room.PlanetPositions) == 0 {
 				room.PlanetPositions = generatePlanetPositions(room.PlanetOrder)
+				room.Orbits = generateOrbits(rand.New(rand.NewSource(room.Galaxy.Seed)), room.PlanetPositions)
+				room.Lanes = generateLanes(rand.New(rand.NewSource(room.Galaxy.Seed)), room.PlanetOrder, room.PlanetPositions, room.unitScale())
 			}
 			room.Started = true
 			room.Turn = 0
+			advanceOrbits(room)
 			// If no humans at start, set deadline to now; runTicker will extend when a human appears
 			if func() bool {
 				for _, pl := range room.Players {
@@ -1526,14 +2257,17 @@ func (gs *GameServer) addBot(roomID string) {
 	}
 
 	b := &Player{
-		ID:                 PlayerID(randID()),
-		Name:               botName,
-		Money:              1000,
-		CurrentPlanet:      "Earth",
-		DestinationPlanet:  "",
-		Inventory:          map[string]int{},
-		InventoryAvgCost:   map[string]int{},
-		Fuel:               fuelCapacity,
+		ID:    PlayerID(randID()),
+		Name:  botName,
+		Money: 1000,
+		Ship: Ship{
+			ID:                flagshipID,
+			CurrentPlanet:     "Earth",
+			DestinationPlanet: "",
+			Inventory:         map[string]int{},
+			InventoryAvgCost:  map[string]int{},
+			Fuel:              fuelCapacity,
+		},
 		Ready:              true, // bots are always ready
 		IsBot:              true,
 		PriceMemory:        make(map[string]*PriceMemory),
@@ -1617,6 +2351,8 @@ func (gs *GameServer) runTicker(room *Room) {
 			continue
 		}
 		room.Turn++
+		// Bodies move along their orbits; lanes are re-measured for new departures
+		advanceOrbits(room)
 		// new turn begins; set the next deadline and reset human ready
 		if onlyBots {
 			room.TurnEndsAt = time.Now()
@@ -1636,13 +2372,19 @@ func (gs *GameServer) runTicker(room *Room) {
 		}
 		// Decrement news and apply active deltas, clamping to static ranges
 		nextNews := make([]NewsItem, 0, len(room.News))
-		ranges := defaultPriceRanges()
+		ranges := priceRanges(room)
 		// Track per-planet per-good bias from price-affecting headlines (sign only)
 		newsBias := map[string]map[string]int{}
 		for _, ni := range room.News {
 			if ni.TurnsRemaining <= 0 {
 				continue
 			}
+			if ni.StartsIn > 0 {
+				// Still a guild bulletin
+				ni.StartsIn--
+				nextNews = append(nextNews, ni)
+				continue
+			}
 			// apply
 			planet := room.Planets[ni.Planet]
 			if planet != nil {
@@ -1745,189 +2487,50 @@ func (gs *GameServer) runTicker(room *Room) {
 				}
 			}
 		}
+		// Facilities adjust local production, prices and fuel on top of the above
+		gs.applyFacilityEffects(room, ranges)
 		// Randomly generate 0-2 news items per turn
 		gs.generateNews(room)
 
-		// resolve travel with fuel consumption
+		// Unanswered pirate encounters from last turn settle as tribute before ships move on
+		gs.settleStaleEncounters(room)
+		// Convoys drop members who split off and set their shared pace
+		gs.maintainConvoys(room)
+		// resolve travel with fuel consumption for every ship in each fleet
 		for _, p := range room.Players {
-			if p.Bankrupt {
-				continue
-			}
-			if p.DestinationPlanet != "" && p.DestinationPlanet != p.CurrentPlanet {
-				// initialize transit if needed
-				if !p.InTransit || p.TransitRemaining <= 0 || p.TransitFrom == "" {
-					p.InTransit = true
-					p.TransitFrom = p.CurrentPlanet
-					p.TransitRemaining = distanceUnits(room, p.CurrentPlanet, p.DestinationPlanet)
-					p.TransitTotal = p.TransitRemaining
-				}
-				// Determine this turn's movement: up to (20 + SpeedBonus) units, but cannot exceed fuel
-				moveCap := 20 + p.SpeedBonus
-				move := minInt(moveCap, p.TransitRemaining)
-				move = minInt(move, p.Fuel)
-				if move <= 0 {
-					// No fuel to progress
-					if p.IsBot {
-						// For bots, cancel transit so they can refuel or adjust plans during AI step
-						p.InTransit = false
-						p.DestinationPlanet = ""
-						p.TransitFrom = ""
-						p.TransitRemaining = 0
-						p.TransitTotal = 0
-					} else {
-						gs.enqueueModal(p, "Insufficient Fuel", "You didn't have enough fuel to make progress toward "+p.DestinationPlanet+".")
-					}
-					// Either we showed a modal (human) and stayed in transit, or we canceled (bot). Proceed to next player.
-					continue
-				}
-				// Consume fuel and reduce remaining distance
-				p.Fuel -= move
-				p.TransitRemaining -= move
-				if p.TransitRemaining <= 0 {
-					// Arrived
-					p.CurrentPlanet = p.DestinationPlanet
-					p.DestinationPlanet = ""
-					p.InTransit = false
-					p.TransitFrom = ""
-					p.TransitRemaining = 0
-					p.TransitTotal = 0
-					// Dock tax on arrival
-					p.Money -= 10
-					gs.logAction(room, p, "Dock tax paid: $10")
-					if !p.IsBot {
-						gs.enqueueModal(p, "Dock Tax", "Docking fee of 10 credits charged at "+p.CurrentPlanet+".")
-					}
-					if p.Money < -500 && !p.Bankrupt {
-						// Queue Game Over first, then mark bankrupt to preserve the modal
-						if !p.IsBot {
-							gs.enqueueModal(p, "Game Over", "Your ship was impounded for unpaid dock taxes. You may continue watching.")
-						}
-						// Impound and bankrupt
-						p.Bankrupt = true
-						// Announce via news ticker
-						room.News = append(room.News, NewsItem{Headline: p.Name + " bankrupted by dock taxes at " + p.CurrentPlanet, Planet: p.CurrentPlanet, TurnsRemaining: 3})
-					}
-				} else {
-					// Still en route
-					p.InTransit = true
-					if !p.IsBot {
-						gs.enqueueModal(p, "In Transit", "You are still in transit towards "+p.DestinationPlanet+".")
-					}
-				}
-			} else {
-				// Staying in same location: apply dock tax if not in transit
-				if !p.InTransit {
-					p.Money -= 10
-					gs.logAction(room, p, "Dock tax paid: $10")
-					if !p.IsBot {
-						gs.enqueueModal(p, "Dock Tax", "Docking fee of 10 credits charged at "+p.CurrentPlanet+".")
-					}
-					if p.Money < -500 && !p.Bankrupt {
-						if !p.IsBot {
-							gs.enqueueModal(p, "Game Over", "Your ship was impounded for unpaid dock taxes. You may continue watching.")
-						}
-						p.Bankrupt = true
-						room.News = append(room.News, NewsItem{Headline: p.Name + " bankrupted by dock taxes at " + p.CurrentPlanet, Planet: p.CurrentPlanet, TurnsRemaining: 3})
-					}
-				}
-			}
-		}
-
-		// Handle facility charges and collection
-		gs.handleFacilities(room)
-
-		// accumulate per-planet production
-		for _, pl := range room.Planets {
-			for g, amt := range pl.Prod {
-				if amt <= 0 {
-					continue
-				}
-				pl.Goods[g] = pl.Goods[g] + amt
-			}
-		}
-
-		// Bot helper functions
-		updateBotPriceMemory := func(bot *Player, planet *Planet, turn int) {
-			if bot.PriceMemory == nil {
-				bot.PriceMemory = make(map[string]*PriceMemory)
-			}
-
-			// Calculate average goods availability
-			totalGoods := 0
-			goodCount := 0
-			for _, qty := range planet.Goods {
-				totalGoods += qty
-				goodCount++
-			}
-			avgGoods := 0
-			if goodCount > 0 {
-				avgGoods = totalGoods / goodCount
-			}
-
-			// Preserve existing purchase history if it exists
-			var existingMemory *PriceMemory
-			if existing, exists := bot.PriceMemory[bot.CurrentPlanet]; exists {
-				existingMemory = existing
-			}
-
-			bot.PriceMemory[bot.CurrentPlanet] = &PriceMemory{
-				Prices:          make(map[string]int),
-				Turn:            turn,
-				GoodsAvg:        avgGoods,
-				LastPurchased:   make(map[string]int),
-				PurchaseAmounts: make(map[string]int),
-				VisitCount:      1,
-				LastProfit:      0,
-				ProfitHistory:   make([]int, 0),
-			}
-
-			// Restore purchase history and visit data if it existed
-			if existingMemory != nil {
-				for good, lastTurn := range existingMemory.LastPurchased {
-					bot.PriceMemory[bot.CurrentPlanet].LastPurchased[good] = lastTurn
-				}
-				for good, amount := range existingMemory.PurchaseAmounts {
-					bot.PriceMemory[bot.CurrentPlanet].PurchaseAmounts[good] = amount
-				}
-				// Increment visit count and calculate profit from this trip
-				bot.PriceMemory[bot.CurrentPlanet].VisitCount = existingMemory.VisitCount + 1
-				currentProfit := bot.Money - bot.LastTripStartMoney
-				bot.PriceMemory[bot.CurrentPlanet].LastProfit = currentProfit
-
-				// Update profit history (keep last 5)
-				profitHistory := existingMemory.ProfitHistory
-				profitHistory = append(profitHistory, currentProfit)
-				if len(profitHistory) > 5 {
-					profitHistory = profitHistory[1:]
+			for _, ship := range p.ships() {
+				if p.Bankrupt {
+					break
 				}
-				bot.PriceMemory[bot.CurrentPlanet].ProfitHistory = profitHistory
-
-				// Update trip start money for next trip
-				bot.LastTripStartMoney = bot.Money
-			}
-
-			for good, price := range planet.Prices {
-				bot.PriceMemory[bot.CurrentPlanet].Prices[good] = price
+				gs.resolveShipTravel(room, p, ship)
 			}
 		}
+		gs.rollEncounters(room)
+		gs.chargeEscorts(room)
+		gs.chargeInsurancePremiums(room)
+		gs.expireMissions(room)
+		gs.refreshMissionBoards(room)
+		gs.refreshPassengerDemand(room)
+		gs.passengerEvents(room)
 
-		recordBotPurchase := func(bot *Player, good string, amount int, turn int) {
-			if bot.PriceMemory == nil {
-				bot.PriceMemory = make(map[string]*PriceMemory)
-			}
+		// Handle facility charges and collection
+		gs.handleFacilities(room)
 
-			if memory, exists := bot.PriceMemory[bot.CurrentPlanet]; exists {
-				if memory.LastPurchased == nil {
-					memory.LastPurchased = make(map[string]int)
-				}
-				if memory.PurchaseAmounts == nil {
-					memory.PurchaseAmounts = make(map[string]int)
+		// accumulate per-planet production
+		for _, pl := range room.Planets {
+			for g, amt := range pl.Prod {
+				if amt <= 0 {
+					continue
 				}
-				memory.LastPurchased[good] = turn
-				memory.PurchaseAmounts[good] = amount
+				pl.Goods[g] = pl.Goods[g] + amt
 			}
 		}
+		gs.runProductionChains(room)
 
+		// Hired captains run their trade routes
+		gs.runTradeRoutes(room)
+
+		// Bot helper functions
 		findBestTradingRoute := func(bot *Player, room *Room) string {
 			if len(bot.PriceMemory) < 2 {
 				// Not enough price data, pick random destination
@@ -1986,7 +2589,7 @@ func (gs *GameServer) runTicker(room *Room) {
 				}
 
 				// Calculate distance and check if reachable
-				distance := distanceUnits(room, bot.CurrentPlanet, planetName)
+				distance := departureUnits(room, bot.CurrentPlanet, planetName)
 				if distance > bot.Fuel {
 					continue // Can't reach
 				}
@@ -2115,12 +2718,13 @@ func (gs *GameServer) runTicker(room *Room) {
 			}
 
 			// Update bot's price memory for current planet
-			updateBotPriceMemory(bp, planet, room.Turn)
+			if bp.PriceMemory == nil {
+				bp.PriceMemory = make(map[string]*PriceMemory)
+			}
+			updateBotPriceMemory(bp.PriceMemory, bp.CurrentPlanet, planet, room.Turn, bp.Money, &bp.LastTripStartMoney)
 
 			// Use intelligent trading logic based on memory, fall back to simple logic
 			useIntelligentTrading := len(bp.PriceMemory) >= 2
-			// reference price ranges per good
-			ranges := defaultPriceRanges()
 
 			// Enhanced selling logic using price memory
 			emergencyMode := bp.Money < 200
@@ -2130,57 +2734,14 @@ func (gs *GameServer) runTicker(room *Room) {
 				if qty <= 0 {
 					continue
 				}
-				price := planet.Prices[g]
-				shouldSell := false
-
-				if useIntelligentTrading {
-					// Check if this is a good price compared to what we remember
-					maxRememberedPrice := 0
-					for _, memory := range bp.PriceMemory {
-						if memPrice, exists := memory.Prices[g]; exists && memPrice > maxRememberedPrice {
-							maxRememberedPrice = memPrice
-						}
-					}
-
-					// Adjust selling threshold based on financial situation
-					sellThreshold := 0.8 // Default: 80% of best remembered price
-					if emergencyMode {
-						sellThreshold = 0.5 // Emergency: sell at 50% of best price
-					} else if lowMoneyMode {
-						sellThreshold = 0.65 // Low money: sell at 65% of best price
-					}
-
-					// Sell if current price meets our threshold
-					if maxRememberedPrice > 0 && price >= int(float64(maxRememberedPrice)*sellThreshold) {
-						shouldSell = true
-					}
-
-					// Emergency selling: sell any profitable goods regardless of remembered prices
-					if emergencyMode && price > 0 {
-						// Check if we can make any profit based on average cost
-						avgCost := bp.InventoryAvgCost[g]
-						if avgCost > 0 && price > avgCost {
-							shouldSell = true
-						}
-					}
-				} else {
-					// Fallback to original logic
-					max := 0
-					if r, ok := ranges[g]; ok {
-						max = r[1]
-					}
-					threshold := (max * 50) / 100
-					if emergencyMode {
-						threshold = (max * 30) / 100 // Lower threshold in emergency
-					}
-					shouldSell = price > threshold
-				}
-
+				price := repSellPrice(room, bp, planet.Name, planet.Prices[g])
+				shouldSell := botShouldSell(bp.PriceMemory, priceRanges(room), g, price, bp.InventoryAvgCost[g], emergencyMode, lowMoneyMode)
 				if shouldSell {
+					proceeds := gs.repSale(room, bp, planet.Name, g, planet.Prices[g], qty)
 					bp.Inventory[g] -= qty
 					planet.Goods[g] += qty
-					proceeds := qty * price
 					bp.Money += proceeds
+					recordSale(bp, proceeds, qty*bp.InventoryAvgCost[g])
 					gs.logAction(room, bp, fmt.Sprintf("Sold %d %s for $%d", qty, g, proceeds))
 					if bp.Inventory[g] <= 0 {
 						delete(bp.Inventory, g)
@@ -2234,6 +2795,7 @@ func (gs *GameServer) runTicker(room *Room) {
 								planet.Goods[g] += sellUnits
 								proceeds := sellUnits * price
 								bp.Money += proceeds
+								recordSale(bp, proceeds, sellUnits*bp.InventoryAvgCost[g])
 								gs.logAction(room, bp, fmt.Sprintf("Liquidated %d %s for $%d to fund fuel", sellUnits, g, proceeds))
 								short -= proceeds
 								if bp.Inventory[g] <= 0 {
@@ -2265,69 +2827,9 @@ func (gs *GameServer) runTicker(room *Room) {
 					if price <= 0 {
 						continue
 					}
+					price = repBuyPrice(room, bp, planet.Name, price)
 
-					shouldBuy := false
-					if useIntelligentTrading {
-						// Check if this is a good price compared to what we remember at other planets
-						maxRememberedPrice := 0
-						for planetName, memory := range bp.PriceMemory {
-							if planetName == bp.CurrentPlanet {
-								continue // Skip current planet
-							}
-							if memPrice, exists := memory.Prices[g]; exists && memPrice > maxRememberedPrice {
-								maxRememberedPrice = memPrice
-							}
-						}
-
-						// Adjust buying threshold based on financial situation
-						buyThreshold := 0.7 // Default: buy if 70% cheaper than best selling price
-						if emergencyMode {
-							buyThreshold = 0.9 // Emergency: buy even if only 10% cheaper
-						} else if lowMoneyMode {
-							buyThreshold = 0.8 // Low money: buy if 20% cheaper
-						}
-
-						// Buy if current price is significantly lower than what we expect to sell for elsewhere
-						if maxRememberedPrice > 0 && price <= int(float64(maxRememberedPrice)*buyThreshold) {
-							shouldBuy = true
-						}
-
-						// If we haven't seen this good elsewhere yet, be more willing to buy in financial trouble
-						if maxRememberedPrice == 0 && (emergencyMode || lowMoneyMode) {
-							shouldBuy = true
-						}
-
-						// Additional check: avoid buying too much if supply is low compared to our memory
-						// But skip this check in emergency mode
-						if shouldBuy && !emergencyMode {
-							currentMemory := bp.PriceMemory[bp.CurrentPlanet]
-							goodsAvailable := planet.Goods[g]
-							if currentMemory != nil && currentMemory.GoodsAvg > 0 {
-								// If this good's availability is much lower than the average we remember,
-								// be more conservative about buying (market might be depleted)
-								if goodsAvailable < currentMemory.GoodsAvg/3 {
-									shouldBuy = false // Skip goods that seem severely depleted
-								}
-							}
-						}
-					} else {
-						// Fallback to original logic
-						max := 0
-						if r, ok := ranges[g]; ok {
-							max = r[1]
-						}
-						if max <= 0 {
-							continue
-						}
-						threshold := (max * 46) / 100
-						if emergencyMode {
-							threshold = (max * 60) / 100 // More willing to buy in emergency
-						} else if lowMoneyMode {
-							threshold = (max * 52) / 100 // Slightly more willing when low on money
-						}
-						shouldBuy = price < threshold
-					}
-
+					shouldBuy := botShouldBuy(bp.PriceMemory, priceRanges(room), bp.CurrentPlanet, planet, g, price, emergencyMode, lowMoneyMode)
 					if !shouldBuy {
 						continue
 					}
@@ -2367,9 +2869,10 @@ func (gs *GameServer) runTicker(room *Room) {
 						delete(bp.InventoryAvgCost, g)
 					}
 					gs.logAction(room, bp, fmt.Sprintf("Bought %d %s for $%d", amount, g, cost))
+					gs.recordPurchase(room, bp, planet.Name, g, amount, cost)
 
 					// Record this purchase to avoid returning too soon to buy more of this good
-					recordBotPurchase(bp, g, amount, room.Turn)
+					recordBotPurchase(bp.PriceMemory, bp.CurrentPlanet, g, amount, room.Turn)
 				}
 			}
 			// Bot refuel behavior (uses local planet fuel price)
@@ -2391,7 +2894,7 @@ func (gs *GameServer) runTicker(room *Room) {
 				// Try to find profitable route based on remembered prices
 				bestDest := findBestTradingRoute(bp, room)
 				if bestDest != "" {
-					dist := distanceUnits(room, bp.CurrentPlanet, bestDest)
+					dist := departureUnits(room, bp.CurrentPlanet, bestDest)
 					if dist <= bp.Fuel {
 						bp.DestinationPlanet = bestDest
 						gs.logAction(room, bp, fmt.Sprintf("Planning profitable route to %s (%d units)", bestDest, dist))
@@ -2425,7 +2928,7 @@ func (gs *GameServer) runTicker(room *Room) {
 						if dest == bp.CurrentPlanet {
 							continue
 						}
-						dist := distanceUnits(room, bp.CurrentPlanet, dest)
+						dist := departureUnits(room, bp.CurrentPlanet, dest)
 						if dist <= bp.Fuel {
 							bp.DestinationPlanet = dest
 							gs.logAction(room, bp, fmt.Sprintf("Traveling to %s (%d units)", dest, dist))
@@ -2439,7 +2942,7 @@ func (gs *GameServer) runTicker(room *Room) {
 		for _, hp := range room.Players {
 			if len(room.PendingBlackOps) > 0 {
 				for _, contract := range room.PendingBlackOps {
-					if room.Turn >= contract.TriggerTurn && hp.ID != contract.Instigator && !hp.Bankrupt {
+					if contract.Kind == "" && room.Turn >= contract.TriggerTurn && hp.ID != contract.Instigator && !hp.Bankrupt {
 						gs.applyBlackOpsHit(room, contract, hp)
 					}
 				}
@@ -2449,6 +2952,11 @@ func (gs *GameServer) runTicker(room *Room) {
 				hp.DestinationPlanet = ""
 				continue
 			}
+			// Data-defined event cards hit humans and bots alike
+			gs.runEventCards(room, hp)
+			if hp.Bankrupt {
+				continue
+			}
 			if hp.IsBot {
 				// Auto-accept capacity upgrade sometimes if affordable
 				if rand.Intn(50) == 0 { // ~2% per turn
@@ -2457,6 +2965,7 @@ func (gs *GameServer) runTicker(room *Room) {
 					if hp.Money >= price {
 						hp.Money -= price
 						hp.CapacityBonus += bonus
+						recordDeduction(hp, deductUpgrades, price)
 						gs.logAction(room, hp, fmt.Sprintf("Purchased cargo upgrade +%d for $%d", bonus, price))
 					}
 				}
@@ -2467,6 +2976,7 @@ func (gs *GameServer) runTicker(room *Room) {
 					if hp.Money >= price {
 						hp.Money -= price
 						hp.SpeedBonus += units
+						recordDeduction(hp, deductUpgrades, price)
 						gs.logAction(room, hp, fmt.Sprintf("Purchased engine upgrade +%d for $%d", units, price))
 					}
 				}
@@ -2477,165 +2987,13 @@ func (gs *GameServer) runTicker(room *Room) {
 					if hp.Money >= price {
 						hp.Money -= price
 						hp.FuelCapacityBonus += units
+						recordDeduction(hp, deductUpgrades, price)
 						gs.logAction(room, hp, fmt.Sprintf("Purchased fuel tank +%d for $%d", units, price))
 					}
 				}
-				// Bot asteroid collision chance mirrored for completeness
-				if rand.Intn(100) == 0 {
-					hp.Inventory = map[string]int{}
-					hp.InventoryAvgCost = map[string]int{}
-					gs.logAction(room, hp, "Asteroid collision: lost all cargo")
-				}
 				// Bots skip modals; move on to next player
 				continue
 			}
-			// Income tax: ~1% chance per turn
-			if rand.Intn(100) == 0 {
-				// total wealth = money + value paid for current cargo
-				totalWealth := hp.Money
-				for g, qty := range hp.Inventory {
-					avg := hp.InventoryAvgCost[g]
-					totalWealth += qty * avg
-				}
-				if totalWealth < 0 {
-					totalWealth = 0
-				}
-				tax := (totalWealth * 8) / 100 // 8%
-				// Deduct tax (can go negative to reflect debt)
-				hp.Money -= tax
-				gs.logAction(room, hp, fmt.Sprintf("Income tax paid: $%d", tax))
-				gs.enqueueModal(hp, "Federation Tax", "Federation income tax is due. "+strconv.Itoa(tax)+" credits due.")
-				if hp.Money < -500 && !hp.Bankrupt {
-					// Queue Game Over first, then mark bankrupt so modal isn't blocked
-					gs.enqueueModal(hp, "Game Over", "Your ship was impounded for unpaid debts. You may continue watching.")
-					hp.Bankrupt = true
-					room.News = append(room.News, NewsItem{Headline: hp.Name + " bankrupted by taxes at " + hp.CurrentPlanet, Planet: hp.CurrentPlanet, TurnsRemaining: 3})
-					// Skip the rest of events for this player this turn
-					continue
-				}
-			}
-			// Lottery win: ~1% chance per turn
-			if rand.Intn(100) == 0 {
-				amt := 500 + rand.Intn(10000-500+1)
-				hp.Money += amt
-				gs.logAction(room, hp, fmt.Sprintf("Lottery winnings: +$%d", amt))
-				gs.enqueueModal(hp, "Lottery Winner!", "You won the lottery and collect "+strconv.Itoa(amt)+" credits!")
-			}
-
-			// Pirate raid: ~0.8% chance per turn - lose money but keep cargo
-			if rand.Intn(125) == 0 {
-				lossPercent := 10 + rand.Intn(21) // 10-30%
-				loss := (hp.Money * lossPercent) / 100
-				if loss > 0 {
-					hp.Money -= loss
-					gs.logAction(room, hp, fmt.Sprintf("Pirate raid: lost $%d (%d%% of credits)", loss, lossPercent))
-					gs.enqueueModal(hp, "Pirate Raid!", "Space pirates demanded tribute and took "+strconv.Itoa(loss)+" credits. Your cargo was spared.")
-				}
-			}
-
-			// Insurance payout: ~0.7% chance per turn
-			if rand.Intn(140) == 0 {
-				payout := 800 + rand.Intn(1201) // 800-2000 credits
-				hp.Money += payout
-				gs.logAction(room, hp, fmt.Sprintf("Insurance payout: +$%d", payout))
-				gs.enqueueModal(hp, "Insurance Payout", "Your ship insurance paid out "+strconv.Itoa(payout)+" credits for a previous incident.")
-			}
-
-			// Cargo spoilage: ~0.6% chance per turn - lose some random goods
-			if rand.Intn(160) == 0 && len(hp.Inventory) > 0 {
-				// Pick a random good from inventory
-				var goods []string
-				for good := range hp.Inventory {
-					goods = append(goods, good)
-				}
-				if len(goods) > 0 {
-					spoiledGood := goods[rand.Intn(len(goods))]
-					currentQty := hp.Inventory[spoiledGood]
-					if currentQty > 0 {
-						spoiledQty := 1 + rand.Intn(minInt(currentQty, 5)) // spoil 1-5 units or all if less
-						hp.Inventory[spoiledGood] -= spoiledQty
-						if hp.Inventory[spoiledGood] <= 0 {
-							delete(hp.Inventory, spoiledGood)
-							delete(hp.InventoryAvgCost, spoiledGood)
-						}
-						gs.logAction(room, hp, fmt.Sprintf("Cargo spoilage: lost %d %s", spoiledQty, spoiledGood))
-						gs.enqueueModal(hp, "Cargo Spoilage", "Storage malfunction caused "+strconv.Itoa(spoiledQty)+" "+spoiledGood+" to spoil and be jettisoned.")
-					}
-				}
-			}
-
-			// Trade route discovery bonus: ~0.5% chance per turn
-			if rand.Intn(200) == 0 {
-				bonus := 1200 + rand.Intn(1801) // 1200-3000 credits
-				hp.Money += bonus
-				gs.logAction(room, hp, fmt.Sprintf("Trade route bonus: +$%d", bonus))
-				gs.enqueueModal(hp, "Trade Route Discovery", "You discovered a lucrative trade route shortcut! Navigation data sold for "+strconv.Itoa(bonus)+" credits.")
-			}
-
-			// Equipment malfunction: ~0.4% chance per turn - repair cost based on upgrades
-			if rand.Intn(250) == 0 && hp.SpeedBonus > 0 {
-				speedLoss := 1 + rand.Intn(minInt(hp.SpeedBonus, 3)) // lose 1-3 speed worth of repairs
-				repairCost := speedLoss * 200
-				hp.Money -= repairCost
-				gs.logAction(room, hp, fmt.Sprintf("Engine malfunction: paid $%d for repairs", repairCost))
-				gs.enqueueModal(hp, "Engine Malfunction", "Your enhanced engines malfunctioned and required emergency repairs costing "+strconv.Itoa(repairCost)+" credits.")
-			}
-
-			// Salvage discovery: ~0.4% chance per turn - free goods
-			if rand.Intn(250) == 0 {
-				// Pick a random good type
-				allGoods := []string{"Water", "Food", "Minerals", "Chemicals", "Energy", "Medicine", "Electronics", "Luxury"}
-				salvageGood := allGoods[rand.Intn(len(allGoods))]
-				salvageQty := 1 + rand.Intn(8) // 1-8 units
-
-				// Check if we have capacity
-				used := inventoryTotal(hp.Inventory)
-				free := shipCapacity + hp.CapacityBonus - used
-				if salvageQty > free {
-					salvageQty = free
-				}
-
-				if salvageQty > 0 {
-					hp.Inventory[salvageGood] += salvageQty
-					// Set a reasonable average cost (market mid-range)
-					ranges := defaultPriceRanges()
-					if rng, exists := ranges[salvageGood]; exists {
-						avgPrice := (rng[0] + rng[1]) / 2
-						oldQty := hp.Inventory[salvageGood] - salvageQty
-						oldAvg := hp.InventoryAvgCost[salvageGood]
-						if oldQty > 0 {
-							newAvg := (oldQty*oldAvg + salvageQty*avgPrice) / hp.Inventory[salvageGood]
-							hp.InventoryAvgCost[salvageGood] = newAvg
-						} else {
-							hp.InventoryAvgCost[salvageGood] = avgPrice
-						}
-					}
-					gs.logAction(room, hp, fmt.Sprintf("Salvage discovered: found %d %s", salvageQty, salvageGood))
-					gs.enqueueModal(hp, "Salvage Discovery", "You found abandoned cargo: "+strconv.Itoa(salvageQty)+" "+salvageGood+" floating in space!")
-				}
-			}
-
-			// Fuel leak: ~0.3% chance per turn - lose some fuel
-			if rand.Intn(330) == 0 && hp.Fuel > 10 {
-				fuelLoss := 5 + rand.Intn(16) // lose 5-20 fuel
-				if fuelLoss > hp.Fuel-5 {     // always leave at least 5 fuel
-					fuelLoss = hp.Fuel - 5
-				}
-				if fuelLoss > 0 {
-					hp.Fuel -= fuelLoss
-					gs.logAction(room, hp, fmt.Sprintf("Fuel leak: lost %d fuel units", fuelLoss))
-					gs.enqueueModal(hp, "Fuel Leak", "A micro-meteorite punctured your fuel tank. You lost "+strconv.Itoa(fuelLoss)+" fuel units.")
-				}
-			}
-
-			// Trade guild membership offer: ~0.2% chance per turn - pay for ongoing benefits
-			if rand.Intn(500) == 0 {
-				membershipFee := 2500 + rand.Intn(2501) // 2500-5000 credits
-				// This could provide ongoing small benefits (not implemented here)
-				gs.logAction(room, hp, fmt.Sprintf("Trade guild membership offered for $%d", membershipFee))
-				gs.enqueueModal(hp, "Trade Guild Invitation", "The Galactic Traders Guild invites you to join for "+strconv.Itoa(membershipFee)+" credits. Membership provides access to exclusive routes and better fuel prices. (This is currently just flavor - no actual benefits implemented)")
-			}
-
 			if !hp.IsBot && len(room.Players) > 1 && !playerHasModalOfKind(hp, "shady-contract") && !roomHasPendingBlackOps(room, hp.ID) {
 				if rand.Intn(400) == 0 { // ~0.25% chance per turn
 					price := 3000 + rand.Intn(3001)
@@ -2645,35 +3003,27 @@ func (gs *GameServer) runTicker(room *Room) {
 					gs.logAction(room, hp, fmt.Sprintf("Received shady contract offer for $%d", price))
 				}
 			}
-			// Asteroid collision: ~1% chance per turn
-			if rand.Intn(100) == 0 {
-				// Lose all cargo
-				hp.Inventory = map[string]int{}
-				hp.InventoryAvgCost = map[string]int{}
-				gs.logAction(room, hp, "Asteroid collision: lost all cargo")
-				gs.enqueueModal(hp, "Asteroid Collision", "Your ship collided with an asteroid and you lost all cargo.")
-			}
-			// Capacity upgrade offer: ~2% chance per turn
-			if rand.Intn(50) == 0 {
-				price := 5000
+			// Capacity upgrade offer: ~2% chance per turn (more often and cheaper at Research Labs)
+			if rand.Intn(upgradeOfferOdds(room, &hp.Ship, 50)) == 0 {
+				price := upgradeOfferPrice(room, &hp.Ship, 5000)
 				bonus := 50
 				mi := ModalItem{ID: randID(), Title: "Shipyard Offer", Body: "Special offer: +" + strconv.Itoa(bonus) + " cargo capacity for $" + strconv.Itoa(price) + ". Accept?", Kind: "upgrade-offer", Price: price, CapacityBonus: bonus}
 				gs.logAction(room, hp, fmt.Sprintf("Offer: +%d cargo for $%d", bonus, price))
 				hp.Modals = append(hp.Modals, mi)
 			}
 			// Speed upgrade offer: 1-10 units, $1000 per unit
-			if rand.Intn(40) == 0 { // ~2.5%/turn
+			if rand.Intn(upgradeOfferOdds(room, &hp.Ship, 40)) == 0 { // ~2.5%/turn
 				units := 1 + rand.Intn(10)
-				ppu := 1000
+				ppu := upgradeOfferPrice(room, &hp.Ship, 1000)
 				price := units * ppu
 				mi := ModalItem{ID: randID(), Title: "Engine Upgrade", Body: "Offer: +" + strconv.Itoa(units) + " speed (units/turn) for $" + strconv.Itoa(ppu) + " per unit (total $" + strconv.Itoa(price) + "). Accept?", Kind: "speed-offer", PricePerUnit: ppu, Units: units}
 				gs.logAction(room, hp, fmt.Sprintf("Offer: +%d speed for $%d total", units, price))
 				hp.Modals = append(hp.Modals, mi)
 			}
 			// Fuel capacity upgrade offer: 20-100 units, $50 per unit
-			if rand.Intn(40) == 0 { // ~2.5%/turn
+			if rand.Intn(upgradeOfferOdds(room, &hp.Ship, 40)) == 0 { // ~2.5%/turn
 				units := 20 + rand.Intn(81) // 20..100
-				ppu := 50
+				ppu := upgradeOfferPrice(room, &hp.Ship, 50)
 				price := units * ppu
 				mi := ModalItem{ID: randID(), Title: "Fuel Tank Expansion", Body: "Offer: +" + strconv.Itoa(units) + " fuel capacity for $" + strconv.Itoa(ppu) + " per unit (total $" + strconv.Itoa(price) + "). Accept?", Kind: "fuelcap-offer", PricePerUnit: ppu, Units: units}
 				gs.logAction(room, hp, fmt.Sprintf("Offer: +%d fuel capacity for $%d total", units, price))
@@ -2687,6 +3037,11 @@ func (gs *GameServer) runTicker(room *Room) {
 				if contract == nil {
 					continue
 				}
+				if contract.Kind != "" {
+					// Targeted covert actions resolve separately below
+					remaining = append(remaining, contract)
+					continue
+				}
 				resolved := true
 				for pid, pl := range room.Players {
 					if pid == contract.Instigator {
@@ -2701,10 +3056,11 @@ func (gs *GameServer) runTicker(room *Room) {
 					}
 				}
 				if resolved {
-					if inst := room.Players[contract.Instigator]; inst != nil && !inst.IsBot && !inst.Bankrupt {
+					// Exposed instigators already heard about it when they were caught
+					if inst := room.Players[contract.Instigator]; inst != nil && !inst.IsBot && !inst.Bankrupt && !contract.Exposed {
 						gs.enqueueModal(inst, "Satisfied Whisper", "Your rivals suffered a streak of unexplained setbacks. No one traced it back to you.")
 					}
-					if inst := room.Players[contract.Instigator]; inst != nil {
+					if inst := room.Players[contract.Instigator]; inst != nil && !contract.Exposed {
 						gs.logAction(room, inst, "Shady contract resolved without exposure")
 					}
 				} else {
@@ -2713,8 +3069,15 @@ func (gs *GameServer) runTicker(room *Room) {
 			}
 			room.PendingBlackOps = remaining
 		}
+		gs.resolveCovertActions(room)
+
+		// Accrue interest, collect loan payments and sweep overdrafts
+		gs.processLoans(room)
+		gs.collectGuildDues(room)
+		gs.assessIncomeTax(room)
 
 		// Handle federation auctions at the end of turn processing
+		gs.reclaimAbandonedFacilities(room)
 		gs.handleFederationAuctions(room)
 
 		// reset human players' ready flags for the new turn
@@ -2728,6 +3091,277 @@ func (gs *GameServer) runTicker(room *Room) {
 	}
 }
 
+// updateBotPriceMemory records the prices seen at planetName into memory, keeping
+// purchase history and tracking profit since the last recorded visit. It is shared
+// by bots and hired route captains.
+func updateBotPriceMemory(memory map[string]*PriceMemory, planetName string, planet *Planet, turn int, money int, lastTripStartMoney *int) {
+	// Calculate average goods availability
+	totalGoods := 0
+	goodCount := 0
+	for _, qty := range planet.Goods {
+		totalGoods += qty
+		goodCount++
+	}
+	avgGoods := 0
+	if goodCount > 0 {
+		avgGoods = totalGoods / goodCount
+	}
+
+	// Preserve existing purchase history if it exists
+	existingMemory := memory[planetName]
+
+	entry := &PriceMemory{
+		Prices:          make(map[string]int),
+		Turn:            turn,
+		GoodsAvg:        avgGoods,
+		LastPurchased:   make(map[string]int),
+		PurchaseAmounts: make(map[string]int),
+		VisitCount:      1,
+		LastProfit:      0,
+		ProfitHistory:   make([]int, 0),
+	}
+	memory[planetName] = entry
+
+	// Restore purchase history and visit data if it existed
+	if existingMemory != nil {
+		for good, lastTurn := range existingMemory.LastPurchased {
+			entry.LastPurchased[good] = lastTurn
+		}
+		for good, amount := range existingMemory.PurchaseAmounts {
+			entry.PurchaseAmounts[good] = amount
+		}
+		// Increment visit count and calculate profit from this trip
+		entry.VisitCount = existingMemory.VisitCount + 1
+		currentProfit := money - *lastTripStartMoney
+		entry.LastProfit = currentProfit
+
+		// Update profit history (keep last 5)
+		profitHistory := existingMemory.ProfitHistory
+		profitHistory = append(profitHistory, currentProfit)
+		if len(profitHistory) > 5 {
+			profitHistory = profitHistory[1:]
+		}
+		entry.ProfitHistory = profitHistory
+
+		// Update trip start money for next trip
+		*lastTripStartMoney = money
+	}
+
+	for good, price := range planet.Prices {
+		entry.Prices[good] = price
+	}
+}
+
+// recordBotPurchase remembers a purchase so the trader avoids returning too soon to buy more.
+func recordBotPurchase(memory map[string]*PriceMemory, planetName string, good string, amount int, turn int) {
+	if entry, exists := memory[planetName]; exists {
+		if entry.LastPurchased == nil {
+			entry.LastPurchased = make(map[string]int)
+		}
+		if entry.PurchaseAmounts == nil {
+			entry.PurchaseAmounts = make(map[string]int)
+		}
+		entry.LastPurchased[good] = turn
+		entry.PurchaseAmounts[good] = amount
+	}
+}
+
+// botShouldSell applies the bot selling thresholds: sell when the local price is close
+// to the best remembered price, or (without enough memory) above a share of the range.
+func botShouldSell(memory map[string]*PriceMemory, ranges map[string][2]int, good string, price int, avgCost int, emergencyMode, lowMoneyMode bool) bool {
+	if len(memory) >= 2 {
+		// Check if this is a good price compared to what we remember
+		maxRememberedPrice := 0
+		for _, mem := range memory {
+			if memPrice, exists := mem.Prices[good]; exists && memPrice > maxRememberedPrice {
+				maxRememberedPrice = memPrice
+			}
+		}
+
+		// Adjust selling threshold based on financial situation
+		sellThreshold := 0.8 // Default: 80% of best remembered price
+		if emergencyMode {
+			sellThreshold = 0.5 // Emergency: sell at 50% of best price
+		} else if lowMoneyMode {
+			sellThreshold = 0.65 // Low money: sell at 65% of best price
+		}
+
+		// Sell if current price meets our threshold
+		if maxRememberedPrice > 0 && price >= int(float64(maxRememberedPrice)*sellThreshold) {
+			return true
+		}
+
+		// Emergency selling: sell any profitable goods regardless of remembered prices
+		if emergencyMode && price > 0 && avgCost > 0 && price > avgCost {
+			return true
+		}
+		return false
+	}
+	// Fallback to original logic
+	max := 0
+	if r, ok := ranges[good]; ok {
+		max = r[1]
+	}
+	threshold := (max * 50) / 100
+	if emergencyMode {
+		threshold = (max * 30) / 100 // Lower threshold in emergency
+	}
+	return price > threshold
+}
+
+// botShouldBuy applies the bot buying thresholds for good at the trader's current planet.
+func botShouldBuy(memory map[string]*PriceMemory, ranges map[string][2]int, currentPlanet string, planet *Planet, good string, price int, emergencyMode, lowMoneyMode bool) bool {
+	if len(memory) >= 2 {
+		// Check if this is a good price compared to what we remember at other planets
+		maxRememberedPrice := 0
+		for planetName, mem := range memory {
+			if planetName == currentPlanet {
+				continue // Skip current planet
+			}
+			if memPrice, exists := mem.Prices[good]; exists && memPrice > maxRememberedPrice {
+				maxRememberedPrice = memPrice
+			}
+		}
+
+		// Adjust buying threshold based on financial situation
+		buyThreshold := 0.7 // Default: buy if 70% cheaper than best selling price
+		if emergencyMode {
+			buyThreshold = 0.9 // Emergency: buy even if only 10% cheaper
+		} else if lowMoneyMode {
+			buyThreshold = 0.8 // Low money: buy if 20% cheaper
+		}
+
+		shouldBuy := false
+		// Buy if current price is significantly lower than what we expect to sell for elsewhere
+		if maxRememberedPrice > 0 && price <= int(float64(maxRememberedPrice)*buyThreshold) {
+			shouldBuy = true
+		}
+
+		// If we haven't seen this good elsewhere yet, be more willing to buy in financial trouble
+		if maxRememberedPrice == 0 && (emergencyMode || lowMoneyMode) {
+			shouldBuy = true
+		}
+
+		// Additional check: avoid buying too much if supply is low compared to our memory
+		// But skip this check in emergency mode
+		if shouldBuy && !emergencyMode {
+			currentMemory := memory[currentPlanet]
+			goodsAvailable := planet.Goods[good]
+			if currentMemory != nil && currentMemory.GoodsAvg > 0 {
+				// If this good's availability is much lower than the average we remember,
+				// be more conservative about buying (market might be depleted)
+				if goodsAvailable < currentMemory.GoodsAvg/3 {
+					shouldBuy = false // Skip goods that seem severely depleted
+				}
+			}
+		}
+		return shouldBuy
+	}
+	// Fallback to original logic
+	max := 0
+	if r, ok := ranges[good]; ok {
+		max = r[1]
+	}
+	if max <= 0 {
+		return false
+	}
+	threshold := (max * 46) / 100
+	if emergencyMode {
+		threshold = (max * 60) / 100 // More willing to buy in emergency
+	} else if lowMoneyMode {
+		threshold = (max * 52) / 100 // Slightly more willing when low on money
+	}
+	return price < threshold
+}
+
+// resolveShipTravel advances one ship along its route for this turn, consuming
+// fuel, and charges dock tax when it arrives or stays docked.
+func (gs *GameServer) resolveShipTravel(room *Room, p *Player, ship *Ship) {
+	flagship := p.isFlagship(ship)
+	notify := !p.IsBot && flagship
+	if ship.DestinationPlanet != "" && ship.DestinationPlanet != ship.CurrentPlanet {
+		// initialize transit if needed
+		if !ship.InTransit || ship.TransitRemaining <= 0 || ship.TransitFrom == "" {
+			// Depart along the shortest open hyperlane path; the distance is locked in
+			// for the trip even as orbits move the planets
+			path, units := lanePath(room, ship.CurrentPlanet, ship.DestinationPlanet, true)
+			if len(room.Lanes) > 0 && path == nil {
+				gs.holdForClosedLanes(room, p, ship, notify)
+				return
+			}
+			ship.LaneHeldFor = ""
+			ship.InTransit = true
+			ship.TransitFrom = ship.CurrentPlanet
+			ship.TransitPath = path
+			ship.TransitRemaining = units
+			ship.TransitTotal = ship.TransitRemaining
+			gs.payLaneTolls(room, p, ship)
+		}
+		// Determine this turn's movement: up to (20 + SpeedBonus) units, but cannot exceed fuel
+		moveCap := convoyMoveCap(room, p, ship, ship.speed())
+		move := minInt(moveCap, ship.TransitRemaining)
+		move = minInt(move, ship.Fuel)
+		if move <= 0 {
+			// No fuel to progress
+			if p.IsBot {
+				// For bots, cancel transit so they can refuel or adjust plans during AI step
+				ship.InTransit = false
+				ship.DestinationPlanet = ""
+				ship.TransitFrom = ""
+				ship.TransitRemaining = 0
+				ship.TransitTotal = 0
+				ship.TransitPath = nil
+			} else {
+				gs.enqueueModal(p, "Insufficient Fuel", "You didn't have enough fuel to make progress toward "+ship.DestinationPlanet+shipSuffix(p, ship)+".")
+			}
+			// Either we showed a modal (human) and stayed in transit, or we canceled (bot).
+			return
+		}
+		// Consume fuel and reduce remaining distance
+		ship.Fuel -= move
+		ship.TransitRemaining -= move
+		if ship.TransitRemaining <= 0 {
+			// Arrived
+			tripTotal := ship.TransitTotal
+			ship.CurrentPlanet = ship.DestinationPlanet
+			ship.DestinationPlanet = ""
+			ship.InTransit = false
+			ship.TransitFrom = ""
+			ship.TransitRemaining = 0
+			ship.TransitTotal = 0
+			ship.TransitPath = nil
+			if !flagship {
+				gs.logAction(room, p, fmt.Sprintf("%s arrived at %s", ship.Name, ship.CurrentPlanet))
+			}
+			gs.chargeDockTax(room, p, ship, notify)
+			gs.completeMissions(room, p, ship)
+			gs.disembarkPassengers(room, p, ship, tripTotal)
+			gs.continueWaypoints(room, p, ship)
+		} else {
+			// Still en route
+			ship.InTransit = true
+			if notify {
+				gs.enqueueModal(p, "In Transit", "You are still in transit towards "+ship.DestinationPlanet+".")
+			}
+		}
+	} else if !ship.InTransit {
+		// Staying in same location: apply dock tax if not in transit
+		ship.LaneHeldFor = ""
+		gs.chargeDockTax(room, p, ship, notify)
+	}
+}
+
+// chargeDockTax applies the per-turn docking fee for a ship; shortfalls go on
+// the player's Federation overdraft.
+func (gs *GameServer) chargeDockTax(room *Room, p *Player, ship *Ship, notify bool) {
+	tax := dockTaxAt(room, p, ship.CurrentPlanet)
+	gs.logAction(room, p, fmt.Sprintf("Dock tax paid: $%d%s", tax, shipSuffix(p, ship)))
+	if notify {
+		gs.enqueueModal(p, "Dock Tax", fmt.Sprintf("Docking fee of %d credits charged at %s.", tax, ship.CurrentPlanet))
+	}
+	gs.chargeWithCredit(room, p, tax, "dock taxes", ship.CurrentPlanet)
+}
+
 func (gs *GameServer) generateNews(room *Room) {
 	// 50% chance to generate one item, 25% chance to generate two
 	count := 0
@@ -2737,6 +3371,8 @@ func (gs *GameServer) generateNews(room *Room) {
 	if rand.Intn(4) == 0 {
 		count = 2
 	}
+	// Larger galaxies carry proportionally more headlines
+	count *= galaxyScaleFactor(room)
 	if count == 0 {
 		return
 	}
@@ -2755,7 +3391,7 @@ func (gs *GameServer) generateNews(room *Room) {
 		goods = append(goods, g)
 	}
 	sort.Strings(goods)
-	ranges := defaultPriceRanges()
+	ranges := priceRanges(room)
 	for i := 0; i < count; i++ {
 		planet := planets[rand.Intn(len(planets))]
 		g := goods[rand.Intn(len(goods))]
@@ -2817,6 +3453,18 @@ func (gs *GameServer) generateNews(room *Room) {
 			room.News = append(room.News, ni)
 			continue
 		}
+		// Occasionally a hyperlane near the planet is closed for the item's duration
+		if rand.Intn(laneClosureChance) == 0 {
+			if ni, ok := laneClosureNews(room, planet, turns); ok {
+				room.News = append(room.News, ni)
+				continue
+			}
+		}
+		// ~10% chance of a pirate activity warning raising encounter odds nearby
+		if rand.Intn(10) == 0 {
+			room.News = append(room.News, NewsItem{Planet: planet, TurnsRemaining: turns, Headline: "Pirate activity reported near " + planet, PirateRisk: encounterNewsChance})
+			continue
+		}
 		// 20% chance to generate a ship fuel price headline (up/down)
 		if rand.Intn(5) == 0 {
 			ni := NewsItem{Planet: planet, TurnsRemaining: turns}
@@ -2870,17 +3518,18 @@ func (gs *GameServer) generateNews(room *Room) {
 			headline = "Market turbulence on " + planet
 		}
 		ni.Headline = headline
+		ni.StartsIn = guildNewsLead
 		room.News = append(room.News, ni)
 	}
 }
 
-func (gs *GameServer) handleBuy(room *Room, p *Player, good string, amount int) {
+func (gs *GameServer) handleBuy(room *Room, p *Player, ship *Ship, good string, amount int) {
 	if amount <= 0 || good == "" {
 		return
 	}
 	room.mu.Lock()
 	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
-	planet := room.Planets[p.CurrentPlanet]
+	planet := room.Planets[ship.CurrentPlanet]
 	if planet == nil {
 		return
 	}
@@ -2889,13 +3538,14 @@ func (gs *GameServer) handleBuy(room *Room, p *Player, good string, amount int)
 	if price <= 0 {
 		return
 	}
+	price = repBuyPrice(room, p, planet.Name, price)
 	// Enforce ship capacity: cap purchase to remaining free slots
-	used := inventoryTotal(p.Inventory)
-	free := shipCapacity + p.CapacityBonus - used
+	used := inventoryTotal(ship.Inventory)
+	free := ship.cargoCapacity() - used
 	if free <= 0 {
 		return
 	}
-	maxByMoney := p.Money / price
+	maxByMoney := spendableFunds(p) / price
 	if amount > maxByMoney {
 		amount = maxByMoney
 	}
@@ -2911,26 +3561,27 @@ func (gs *GameServer) handleBuy(room *Room, p *Player, good string, amount int)
 	cost := amount * price
 	p.Money -= cost
 	planet.Goods[good] -= amount
-	oldQty := p.Inventory[good]
-	oldAvg := p.InventoryAvgCost[good]
+	oldQty := ship.Inventory[good]
+	oldAvg := ship.InventoryAvgCost[good]
 	newQty := oldQty + amount
-	p.Inventory[good] = newQty
+	ship.Inventory[good] = newQty
 	if newQty > 0 {
 		newAvg := (oldQty*oldAvg + amount*price) / newQty
-		p.InventoryAvgCost[good] = newAvg
+		ship.InventoryAvgCost[good] = newAvg
 	} else {
-		delete(p.InventoryAvgCost, good)
+		delete(ship.InventoryAvgCost, good)
 	}
-	gs.logAction(room, p, fmt.Sprintf("Purchased %d %s for $%d", amount, good, cost))
+	gs.logAction(room, p, fmt.Sprintf("Purchased %d %s for $%d%s", amount, good, cost, shipSuffix(p, ship)))
+	gs.recordPurchase(room, p, planet.Name, good, amount, cost)
 }
 
-func (gs *GameServer) handleSell(room *Room, p *Player, good string, amount int) {
+func (gs *GameServer) handleSell(room *Room, p *Player, ship *Ship, good string, amount int) {
 	if amount <= 0 || good == "" {
 		return
 	}
 	room.mu.Lock()
 	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
-	planet := room.Planets[p.CurrentPlanet]
+	planet := room.Planets[ship.CurrentPlanet]
 	if planet == nil {
 		return
 	}
@@ -2938,22 +3589,23 @@ func (gs *GameServer) handleSell(room *Room, p *Player, good string, amount int)
 	if price <= 0 {
 		return
 	}
-	owned := p.Inventory[good]
+	owned := ship.Inventory[good]
 	if amount > owned {
 		amount = owned
 	}
 	if amount <= 0 {
 		return
 	}
-	p.Inventory[good] -= amount
+	proceeds := gs.repSale(room, p, planet.Name, good, price, amount)
+	ship.Inventory[good] -= amount
 	planet.Goods[good] += amount
-	proceeds := amount * price
 	p.Money += proceeds
-	if p.Inventory[good] <= 0 {
-		delete(p.Inventory, good)
-		delete(p.InventoryAvgCost, good)
+	recordSale(p, proceeds, amount*ship.InventoryAvgCost[good])
+	if ship.Inventory[good] <= 0 {
+		delete(ship.Inventory, good)
+		delete(ship.InventoryAvgCost, good)
 	}
-	gs.logAction(room, p, fmt.Sprintf("Sold %d %s for $%d", amount, good, proceeds))
+	gs.logAction(room, p, fmt.Sprintf("Sold %d %s for $%d%s", amount, good, proceeds, shipSuffix(p, ship)))
 }
 
 func (gs *GameServer) handleAuctionBid(room *Room, p *Player, auctionID string, bid int) {
@@ -2966,32 +3618,47 @@ func (gs *GameServer) handleAuctionBid(room *Room, p *Player, auctionID string,
 	log.Printf("Room %s: Player %s attempting to bid %d for auction %s", room.ID, p.Name, bid, auctionID)
 
 	// Check if auction exists and is still active
-	if room.ActiveAuction == nil {
-		log.Printf("Room %s: Auction bid rejected - no active auction", room.ID)
+	a := findAuction(room, auctionID)
+	if a == nil {
+		log.Printf("Room %s: Auction bid rejected - auction %s not active", room.ID, auctionID)
 		gs.enqueueModal(p, "Auction Ended", "This auction is no longer active.")
 		return
 	}
-	if room.ActiveAuction.ID != auctionID {
-		log.Printf("Room %s: Auction bid rejected - ID mismatch (active: %s, requested: %s)",
-			room.ID, room.ActiveAuction.ID, auctionID)
-		gs.enqueueModal(p, "Auction Ended", "This auction is no longer active.")
+	if a.Seller == p.ID {
+		gs.enqueueModal(p, "Bid Rejected", "You can't bid on your own lot.")
 		return
 	}
-
-	// Check if player can afford the bid
-	if p.Money < bid {
-		log.Printf("Room %s: Player %s cannot afford bid %d (has %d)", room.ID, p.Name, bid, p.Money)
-		gs.enqueueModal(p, "Insufficient Funds", "You don't have enough credits for this bid.")
+	if auctionBarred(room, p, a) {
+		gs.enqueueModal(p, "Bid Rejected", "Your standing with the "+planetFaction(room, a.Planet)+" is too low to bid at their auctions.")
 		return
 	}
 
-	// Record the bid
-	room.ActiveAuction.Bids[p.ID] = bid
+	// Validate against the auction format and reserve the funds
+	if reason := gs.recordBid(room, a, p, bid); reason != "" {
+		log.Printf("Room %s: Player %s bid %d rejected: %s", room.ID, p.Name, bid, reason)
+		gs.enqueueModal(p, "Bid Rejected", reason)
+		return
+	}
+	bid = a.Bids[p.ID]
+	if a.Format == auctionEnglish && a.TurnsLeft < 2 {
+		// Soft close: a late bid gives rivals one more turn to respond
+		a.TurnsLeft = 2
+	}
 	log.Printf("Room %s: Recorded bid %d from player %s for auction %s", room.ID, bid, p.Name, auctionID)
-	gs.logAction(room, p, fmt.Sprintf("Placed auction bid of $%d for %s on %s", bid, room.ActiveAuction.FacilityType, room.ActiveAuction.Planet))
+	gs.logAction(room, p, fmt.Sprintf("Placed auction bid of $%d for %s", bid, lotLabel(a)))
+	if a.Format == auctionDutch {
+		// The first captain to accept a Dutch price wins outright
+		if a.Seller != "" {
+			gs.settleListing(room, a)
+		} else {
+			gs.endFederationAuction(room)
+			room.ActiveAuction = nil
+		}
+		return
+	}
 
 	// Confirm bid to player
-	gs.enqueueModal(p, "Bid Placed", fmt.Sprintf("Your bid of $%d for the %s on %s has been recorded.", bid, room.ActiveAuction.FacilityType, room.ActiveAuction.Planet))
+	gs.enqueueModal(p, "Bid Placed", fmt.Sprintf("Your bid of $%d for the %s has been recorded.", bid, lotLabel(a)))
 }
 
 func (gs *GameServer) sendRoomState(room *Room, only *Player) {
@@ -3015,7 +3682,7 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 		if pp.Bankrupt {
 			moneyField = "Bankrupt"
 		}
-		cargoValue := inventoryValue(pp.Inventory, pp.InventoryAvgCost)
+		cargoValue := totalCargoValue(pp)
 		upgradeValue := pp.UpgradeInvestment
 		facilityValue := pp.FacilityInvestment
 		players = append(players, map[string]interface{}{
@@ -3031,6 +3698,9 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 			"cargoValue":         cargoValue,
 			"upgradeValue":       upgradeValue,
 			"facilityInvestment": facilityValue,
+			"fleetValue":         pp.FleetInvestment,
+			"fleet":              fleetPositions(pp),
+			"debt":               loanBalance(pp),
 		})
 	}
 
@@ -3056,8 +3726,13 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 				"ownerId":       facility.Owner,
 				"ownerName":     ownerName,
 				"usageCharge":   facility.UsageCharge,
+				"effect":        facilityEffect(facility.Type),
 				"accruedMoney":  facility.AccruedMoney,
 				"purchasePrice": facility.PurchasePrice,
+				"shares":        cloneShares(facilityShares(facility)),
+				"shareAsks":     shareAsksPayload(facility),
+				"recipe":        facility.Recipe,
+				"storage":       cloneIntMap(facility.Storage),
 			})
 		}
 		if len(entries) > 0 {
@@ -3138,6 +3813,33 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 				if pp.Modals[0].SuggestedBid != 0 {
 					nm["suggestedBid"] = pp.Modals[0].SuggestedBid
 				}
+				if pp.Modals[0].AuctionFormat != "" {
+					nm["auctionFormat"] = pp.Modals[0].AuctionFormat
+				}
+				if pp.Modals[0].FacilityID != "" {
+					nm["facilityId"] = pp.Modals[0].FacilityID
+				}
+				if pp.Modals[0].SellerID != "" {
+					nm["sellerId"] = pp.Modals[0].SellerID
+				}
+				if pp.Modals[0].EncounterID != "" {
+					nm["encounterId"] = pp.Modals[0].EncounterID
+				}
+				if pp.Modals[0].InviterID != "" {
+					nm["inviterId"] = pp.Modals[0].InviterID
+				}
+				if pp.Modals[0].Destination != "" {
+					nm["destination"] = pp.Modals[0].Destination
+				}
+				if len(pp.Modals[0].Choices) > 0 {
+					nm["choices"] = choicesPayload(room, pp, pp.Modals[0])
+				}
+				if pp.Modals[0].EventID != "" {
+					nm["eventId"] = pp.Modals[0].EventID
+				}
+				if pp.Modals[0].ExpiresTurn != 0 {
+					nm["expiresTurn"] = pp.Modals[0].ExpiresTurn
+				}
 			} else {
 				nm = map[string]interface{}{}
 			}
@@ -3169,9 +3871,14 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 						}
 						return out
 					}(),
+					"hyperlanes": lanesPayload(room),
+					"orbits":     orbitsPayload(room),
 					"news": func() []map[string]interface{} {
 						arr := make([]map[string]interface{}, 0, len(room.News))
 						for _, n := range room.News {
+							if n.StartsIn > 0 {
+								continue
+							}
 							arr = append(arr, map[string]interface{}{
 								"headline":       n.Headline,
 								"planet":         n.Planet,
@@ -3205,6 +3912,10 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 					"upgradeInvestment":  pp.UpgradeInvestment,
 					"upgradeValue":       pp.UpgradeInvestment,
 					"cargoValue":         0,
+					"fleetValue":         pp.FleetInvestment,
+					"fleet":              []map[string]interface{}{},
+					"loan":               loanPayload(pp),
+					"shareHoldings":      shareHoldingsPayload(room, pp),
 					"modal":              nm,
 					"marketMemory":       buildMarketPayload(pp.MarketMemory),
 				},
@@ -3234,7 +3945,7 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 				visPrices[k] = v
 			}
 			// attach static price ranges for each visible good
-			ranges := defaultPriceRanges()
+			ranges := priceRanges(room)
 			visRanges := map[string][2]int{}
 			for g := range visGoods {
 				if r, ok := ranges[g]; ok {
@@ -3266,6 +3977,15 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 				"fuelPrice":   planet.FuelPrice,
 			}
 		}
+		// Ships docked elsewhere keep the market memory fresh for their planets too
+		for _, ship := range pp.Fleet {
+			if ship == nil || ship.InTransit || (planet != nil && ship.CurrentPlanet == planet.Name) {
+				continue
+			}
+			if fp := room.Planets[ship.CurrentPlanet]; fp != nil {
+				pp.MarketMemory[fp.Name] = snapshotMarket(room, fp)
+			}
+		}
 		var nextModal map[string]interface{}
 		if len(pp.Modals) > 0 {
 			nm := map[string]interface{}{"id": pp.Modals[0].ID, "title": pp.Modals[0].Title, "body": pp.Modals[0].Body}
@@ -3305,6 +4025,33 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 			if pp.Modals[0].SuggestedBid != 0 {
 				nm["suggestedBid"] = pp.Modals[0].SuggestedBid
 			}
+			if pp.Modals[0].AuctionFormat != "" {
+				nm["auctionFormat"] = pp.Modals[0].AuctionFormat
+			}
+			if pp.Modals[0].FacilityID != "" {
+				nm["facilityId"] = pp.Modals[0].FacilityID
+			}
+			if pp.Modals[0].SellerID != "" {
+				nm["sellerId"] = pp.Modals[0].SellerID
+			}
+			if pp.Modals[0].EncounterID != "" {
+				nm["encounterId"] = pp.Modals[0].EncounterID
+			}
+			if pp.Modals[0].InviterID != "" {
+				nm["inviterId"] = pp.Modals[0].InviterID
+			}
+			if pp.Modals[0].Destination != "" {
+				nm["destination"] = pp.Modals[0].Destination
+			}
+			if len(pp.Modals[0].Choices) > 0 {
+				nm["choices"] = choicesPayload(room, pp, pp.Modals[0])
+			}
+			if pp.Modals[0].EventID != "" {
+				nm["eventId"] = pp.Modals[0].EventID
+			}
+			if pp.Modals[0].ExpiresTurn != 0 {
+				nm["expiresTurn"] = pp.Modals[0].ExpiresTurn
+			}
 			nextModal = nm
 		} else {
 			nextModal = map[string]interface{}{}
@@ -3339,18 +4086,37 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 					}
 					return out
 				}(),
+				"hyperlanes": lanesPayload(room),
+				"orbits":     orbitsPayload(room),
 				"news": func() []map[string]interface{} {
 					arr := make([]map[string]interface{}, 0, len(room.News))
 					for _, n := range room.News {
-						arr = append(arr, map[string]interface{}{
+						if n.StartsIn > 0 && pp.Guild == nil {
+							continue
+						}
+						item := map[string]interface{}{
 							"headline":       n.Headline,
 							"planet":         n.Planet,
 							"turnsRemaining": n.TurnsRemaining,
-						})
+						}
+						if n.StartsIn > 0 {
+							item["guildBulletin"] = true
+							item["startsIn"] = n.StartsIn
+						}
+						arr = append(arr, item)
 					}
 					return arr
 				}(),
-				"facilities": facilityOverview,
+				"facilities":  facilityOverview,
+				"bankPlanets": append([]string(nil), room.BankPlanets...),
+				"factions":    factionsPayload(room),
+				"covert":      covertPayload(room),
+				"convoys":     convoysPayload(room),
+				"galaxy":      galaxyPayload(room),
+				"events":      eventsPayload(room),
+				"recipes":     recipesPayload(),
+				"auction":     auctionPayload(room, room.ActiveAuction, pp),
+				"auctions":    auctionsPayload(room, pp),
 			},
 			"you": map[string]interface{}{
 				"id":                 pp.ID,
@@ -3368,13 +4134,35 @@ func (gs *GameServer) sendRoomState(room *Room, only *Player) {
 				"transitFrom":        pp.TransitFrom,
 				"transitRemaining":   pp.TransitRemaining,
 				"transitTotal":       pp.TransitTotal,
+				"transitPath":        append([]string(nil), pp.TransitPath...),
 				"capacity":           shipCapacity + pp.CapacityBonus,
 				"fuelCapacity":       fuelCapacity + pp.FuelCapacityBonus,
 				"speedPerTurn":       20 + pp.SpeedBonus,
 				"facilityInvestment": pp.FacilityInvestment,
 				"upgradeInvestment":  pp.UpgradeInvestment,
 				"upgradeValue":       pp.UpgradeInvestment,
-				"cargoValue":         inventoryValue(pp.Inventory, pp.InventoryAvgCost),
+				"cargoValue":         totalCargoValue(pp),
+				"fleetValue":         pp.FleetInvestment,
+				"fleet":              fleetPayload(room, pp),
+				"loan":               loanPayload(pp),
+				"shareHoldings":      shareHoldingsPayload(room, pp),
+				"missions":           cloneMissions(pp.Missions),
+				"missionBoard":       missionBoardPayload(room),
+				"passengers":         clonePassengers(pp.Passengers),
+				"berths":             pp.berths(),
+				"passengerDemand":    passengerDemandPayload(room),
+				"reputation":         reputationPayload(pp),
+				"incidents":          incidentsPayload(room, pp),
+				"protectedUntil":     pp.ProtectedUntil,
+				"weapons":            pp.Weapons,
+				"shields":            pp.Shields,
+				"pirate":             pp.Pirate,
+				"escort":             pp.Escort,
+				"convoyId":           pp.ConvoyID,
+				"waypoints":          append([]string(nil), pp.Waypoints...),
+				"guild":              guildPayload(room, pp),
+				"insurance":          insurancePayload(room, pp),
+				"tax":                taxPayload(room, pp),
 				"modal":              nextModal,
 				"marketMemory":       buildMarketPayload(pp.MarketMemory),
 			},
@@ -3437,6 +4225,26 @@ func (gs *GameServer) closeRoom(roomID string) {
 		pl.TransitFrom = ""
 		pl.TransitRemaining = 0
 		pl.TransitTotal = 0
+		pl.TransitPath = nil
+		pl.Fleet = nil
+		pl.FleetInvestment = 0
+		pl.Loan = nil
+		pl.Missions = nil
+		pl.Passengers = nil
+		pl.Reputation = nil
+		pl.TradeProgress = nil
+		pl.MarketBuys = nil
+		pl.ProtectedUntil = 0
+		pl.Pirate = false
+		pl.Weapons = 0
+		pl.Shields = 0
+		pl.Escort = false
+		pl.Waypoints = nil
+		pl.Guild = nil
+		pl.Insurance = nil
+		pl.Tax = nil
+		pl.ConvoyID = ""
+		pl.ReservedFunds = 0
 		pl.Ready = false
 		pl.EndGame = false
 		if pl.conn != nil {
@@ -3663,6 +4471,25 @@ func cloneMarketMemory(in map[string]*MarketSnapshot) map[string]*MarketSnapshot
 	return out
 }
 
+// snapshotMarket captures the current market state of a planet for a player's memory.
+func snapshotMarket(room *Room, planet *Planet) *MarketSnapshot {
+	ranges := priceRanges(room)
+	rangeCopy := make(map[string][2]int, len(planet.Goods))
+	for g := range planet.Goods {
+		if r, ok := ranges[g]; ok {
+			rangeCopy[g] = r
+		}
+	}
+	return &MarketSnapshot{
+		Turn:        room.Turn,
+		UpdatedAt:   time.Now().UnixMilli(),
+		Goods:       cloneIntMap(planet.Goods),
+		Prices:      cloneIntMap(planet.Prices),
+		PriceRanges: rangeCopy,
+		FuelPrice:   planet.FuelPrice,
+	}
+}
+
 func cloneModals(in []ModalItem) []ModalItem {
 	if in == nil {
 		return nil
@@ -3756,7 +4583,7 @@ func roomHasPendingBlackOps(room *Room, instigator PlayerID) bool {
 		if contract == nil {
 			continue
 		}
-		if contract.Instigator == instigator {
+		if contract.Kind == "" && contract.Instigator == instigator {
 			return true
 		}
 	}
@@ -3773,6 +4600,9 @@ func (gs *GameServer) applyBlackOpsHit(room *Room, contract *BlackOpsContract, t
 	if contract.Applied[target.ID] {
 		return
 	}
+	if gs.foilBlackOpsHit(room, contract, target) {
+		return
+	}
 
 	var (
 		desc    string
@@ -3874,54 +4704,31 @@ func (gs *GameServer) applyBlackOpsHit(room *Room, contract *BlackOpsContract, t
 	if inst := room.Players[contract.Instigator]; inst != nil {
 		gs.logAction(room, inst, fmt.Sprintf("Black ops impacted %s (%s)", target.Name, desc))
 	}
+	inc := gs.recordIncident(room, contract, target, desc)
+	gs.botInvestigate(room, target, inc)
 }
 
 // generatePlanetPositions returns normalized positions in [0,1]x[0,1] with a minimal spacing
 func generatePlanetPositions(names []string) map[string][2]float64 {
-	m := make(map[string][2]float64, len(names))
-	if len(names) == 0 {
-		return m
-	}
-	minDist := 0.18 // minimal distance between planets (normalized)
-	margin := 0.08  // keep away from edges
-	placed := make([][2]float64, 0, len(names))
-	for _, n := range names {
-		var x, y float64
-		ok := false
-		for tries := 0; tries < 200; tries++ {
-			x = margin + rand.Float64()*(1-2*margin)
-			y = margin + rand.Float64()*(1-2*margin)
-			good := true
-			for _, p := range placed {
-				dx := p[0] - x
-				dy := p[1] - y
-				if math.Hypot(dx, dy) < minDist {
-					good = false
-					break
-				}
-			}
-			if good {
-				ok = true
-				break
-			}
-		}
-		if !ok {
-			// fallback without spacing guarantee
-			x = margin + rand.Float64()*(1-2*margin)
-			y = margin + rand.Float64()*(1-2*margin)
-		}
-		placed = append(placed, [2]float64{x, y})
-		m[n] = [2]float64{x, y}
-	}
-	return m
+	return placePlanets(rand.New(rand.NewSource(rand.Int63())), names, classicMinSpacing)
 }
 
-// distanceUnits computes integer travel cost between two planets using normalized positions.
+// distanceUnits computes integer travel cost between two planets: the shortest open
+// hyperlane path, or the shortest over all lanes while closures cut every route.
+// Rooms without lanes use straight-line distance between normalized positions.
 // Returns at least 1 for distinct planets; 0 if names are empty or same.
 func distanceUnits(room *Room, from, to string) int {
 	if from == "" || to == "" || from == to {
 		return 0
 	}
+	if len(room.Lanes) > 0 {
+		if path, units := lanePath(room, from, to, true); path != nil {
+			return units
+		}
+		if path, units := lanePath(room, from, to, false); path != nil {
+			return units
+		}
+	}
 	pos := room.PlanetPositions
 	if len(pos) == 0 {
 		// fallback fixed cost when positions unknown
@@ -3932,34 +4739,25 @@ func distanceUnits(room *Room, from, to string) int {
 	if !okA || !okB {
 		return 5
 	}
-	dx := a[0] - b[0]
-	dy := a[1] - b[1]
-	d := math.Hypot(dx, dy)
-	// scale normalized distance (~0..1.4) to a reasonable unit cost range
-	// Use ceil to make longer hops cost a bit more; ensure minimum 1
-	units := int(math.Ceil(d * 40))
-	if units < 1 {
-		units = 1
-	}
-	return units
+	return positionUnits(a, b, room.unitScale())
 }
 
-// handleRefuel processes a refuel request for the player.
+// handleRefuel processes a refuel request for one of the player's ships.
 // amount<=0 means "fill to max you can afford and capacity".
-func (gs *GameServer) handleRefuel(room *Room, p *Player, amount int) {
+func (gs *GameServer) handleRefuel(room *Room, p *Player, ship *Ship, amount int) {
 	room.mu.Lock()
 	defer func() { room.mu.Unlock(); gs.sendRoomState(room, p) }()
 	if amount < 0 {
 		amount = 0
 	}
-	planet := room.Planets[p.CurrentPlanet]
+	planet := room.Planets[ship.CurrentPlanet]
 	price := 10
 	if planet != nil {
 		if planet.FuelPrice > 0 {
 			price = planet.FuelPrice
 		}
 	}
-	maxCap := (fuelCapacity + p.FuelCapacityBonus) - p.Fuel
+	maxCap := ship.maxFuel() - ship.Fuel
 	if maxCap <= 0 {
 		return
 	}
@@ -3981,20 +4779,23 @@ func (gs *GameServer) handleRefuel(room *Room, p *Player, amount int) {
 	}
 	cost := amount * price
 	p.Money -= cost
-	p.Fuel += amount
+	ship.Fuel += amount
 	if amount > 0 && cost > 0 {
-		gs.logAction(room, p, fmt.Sprintf("Purchased %d fuel for $%d", amount, cost))
+		gs.logAction(room, p, fmt.Sprintf("Purchased %d fuel for $%d%s", amount, cost, shipSuffix(p, ship)))
 	}
 }
 
 // handleFederationAuctions manages facility auctions across all turns
 func (gs *GameServer) handleFederationAuctions(room *Room) {
+	gs.processPlayerAuctions(room)
+
 	// Check if an auction is ending
 	if room.ActiveAuction != nil {
 		log.Printf("Room %s: Processing auction %s, turns left: %d", room.ID, room.ActiveAuction.ID, room.ActiveAuction.TurnsLeft)
+		settled := gs.advanceAuction(room, room.ActiveAuction)
 		room.ActiveAuction.TurnsLeft--
 
-		if room.ActiveAuction.TurnsLeft <= 0 {
+		if settled || room.ActiveAuction.TurnsLeft <= 0 {
 			log.Printf("Room %s: Auction %s ending with %d bids", room.ID, room.ActiveAuction.ID, len(room.ActiveAuction.Bids))
 			// Auction ends - determine winner and create facility
 			gs.endFederationAuction(room)
@@ -4002,8 +4803,10 @@ func (gs *GameServer) handleFederationAuctions(room *Room) {
 		}
 	}
 
-	// Randomly start new auctions (~2% chance per turn)
-	if room.ActiveAuction == nil && rand.Intn(50) == 0 {
+	// Randomly start new auctions (~2% chance per turn, more often on larger maps); reclaimed
+	// facilities go back on the block right away
+	_, reclaimed := reclaimedFacility(room)
+	if room.ActiveAuction == nil && (reclaimed != nil || rand.Intn(50) < galaxyScaleFactor(room)) {
 		log.Printf("Room %s: Starting new federation auction", room.ID)
 		gs.startFederationAuction(room)
 	}
@@ -4011,6 +4814,10 @@ func (gs *GameServer) handleFederationAuctions(room *Room) {
 
 // startFederationAuction creates a new facility auction
 func (gs *GameServer) startFederationAuction(room *Room) {
+	if planet, f := reclaimedFacility(room); f != nil {
+		gs.openFederationAuction(room, f.Type, planet.Name, f.UsageCharge, maxInt(f.UsageCharge*10, federationResaleValue(f)), f.ID)
+		return
+	}
 	// Select random planet that still has capacity for additional facilities
 	availablePlanets := []string{}
 	minFacilities := math.MaxInt
@@ -4037,41 +4844,61 @@ func (gs *GameServer) startFederationAuction(room *Room) {
 	planet := availablePlanets[rand.Intn(len(availablePlanets))]
 
 	// Select random facility type with usage charge
-	facilityTypes := []struct {
-		name   string
-		charge int
-	}{
-		{"Mining Station", 25 + rand.Intn(26)}, // 25-50 per turn
-		{"Trade Hub", 15 + rand.Intn(21)},      // 15-35 per turn
-		{"Refinery", 20 + rand.Intn(31)},       // 20-50 per turn
-		{"Research Lab", 30 + rand.Intn(21)},   // 30-50 per turn
-		{"Repair Dock", 10 + rand.Intn(16)},    // 10-25 per turn
-		{"Fuel Depot", 8 + rand.Intn(13)},      // 8-20 per turn
-	}
-
-	facility := facilityTypes[rand.Intn(len(facilityTypes))]
+	type facilityType struct {
+		name       string
+		charge     int
+		chokepoint bool // only offered at chokepoints
+	}
+	facilityTypes := []facilityType{
+		{"Mining Station", 25 + rand.Intn(26), false}, // 25-50 per turn
+		{"Trade Hub", 15 + rand.Intn(21), false},      // 15-35 per turn
+		{"Refinery", 20 + rand.Intn(31), false},       // 20-50 per turn
+		{"Research Lab", 30 + rand.Intn(21), false},   // 30-50 per turn
+		{"Repair Dock", 10 + rand.Intn(16), false},    // 10-25 per turn
+		{"Fuel Depot", 8 + rand.Intn(13), false},      // 8-20 per turn
+		{"Factory", 20 + rand.Intn(21), false},        // 20-40 per turn
+		{"Gate Station", 10 + rand.Intn(11), true},    // 10-20 per turn, plus tolls on every lane through it
+	}
+
+	// Chokepoints without a Gate Station offer one a third of the time
+	gateSite := isChokepoint(room, planet) && !planetHasFacility(room, planet, "Gate Station") && rand.Intn(3) == 0
+	eligible := []facilityType{}
+	for _, ft := range facilityTypes {
+		if ft.chokepoint == gateSite {
+			eligible = append(eligible, ft)
+		}
+	}
+	facility := eligible[rand.Intn(len(eligible))]
 	suggestedBid := facility.charge * 10
+	gs.openFederationAuction(room, facility.name, planet, facility.charge, suggestedBid, "")
+}
 
+// openFederationAuction announces an auction and collects bot bids. facilityID is
+// set when the lot is an existing facility the Federation has reclaimed.
+func (gs *GameServer) openFederationAuction(room *Room, facilityType, planet string, charge, suggestedBid int, facilityID string) {
 	// Create auction
 	auctionID := fmt.Sprintf("auction_%d_%d", room.Turn, rand.Intn(1000))
 	room.ActiveAuction = &FederationAuction{
 		ID:           auctionID,
-		FacilityType: facility.name,
+		FacilityType: facilityType,
 		Planet:       planet,
-		UsageCharge:  facility.charge,
+		UsageCharge:  charge,
 		SuggestedBid: suggestedBid,
 		Bids:         make(map[PlayerID]int),
-		TurnsLeft:    1, // Auction lasts for the next turn
+		TurnsLeft:    1, // Sealed auctions last for the next turn
+		FacilityID:   facilityID,
 	}
+	a := room.ActiveAuction
+	setupAuctionFormat(a, auctionFormats[rand.Intn(len(auctionFormats))])
 
-	log.Printf("Room %s: Created auction %s for %s on %s (charge: %d, suggested bid: %d)",
-		room.ID, auctionID, facility.name, planet, facility.charge, suggestedBid)
+	log.Printf("Room %s: Created %s auction %s for %s on %s (charge: %d, suggested bid: %d)",
+		room.ID, a.Format, auctionID, facilityType, planet, charge, suggestedBid)
 
 	// Send auction modal to all players
 	for _, p := range room.Players {
 		gs.enqueueModal(p, "Federation Facility Auction",
-			fmt.Sprintf("The Galactic Federation is auctioning a %s on %s. Non-owners will pay %d credits per turn when docking. Enter your bid below.",
-				facility.name, planet, facility.charge),
+			fmt.Sprintf("The Galactic Federation is auctioning a %s on %s in a %s auction. %s Non-owners will pay %d credits per turn when docking. %s Enter your bid below.",
+				facilityType, planet, auctionFormatLabel(a.Format), facilityEffect(facilityType), charge, auctionRules(a)),
 		)
 
 		// Set modal with auction details
@@ -4079,29 +4906,17 @@ func (gs *GameServer) startFederationAuction(room *Room) {
 			lastModal := &p.Modals[len(p.Modals)-1]
 			lastModal.Kind = "auction"
 			lastModal.AuctionID = auctionID
-			lastModal.FacilityType = facility.name
+			lastModal.FacilityType = facilityType
 			lastModal.Planet = planet
-			lastModal.UsageCharge = facility.charge
+			lastModal.UsageCharge = charge
 			lastModal.SuggestedBid = suggestedBid
-		}
-
-		// Auto-bid for bots
-		if p.IsBot && !p.Bankrupt {
-			// Bots bid around the suggested amount but never more than they can afford
-			maxBid := p.Money - 200 // Keep 200 credits as buffer
-			if maxBid > 0 {
-				// Bid 80-120% of suggested, capped at what they can afford
-				bidVariation := suggestedBid + rand.Intn(suggestedBid/2) - suggestedBid/4
-				bid := minInt(bidVariation, maxBid)
-				if bid > 0 {
-					room.ActiveAuction.Bids[p.ID] = bid
-					gs.logAction(room, p, fmt.Sprintf("Auto-placed auction bid of $%d for %s on %s", bid, facility.name, planet))
-				}
-			}
+			lastModal.FacilityID = facilityID
+			lastModal.AuctionFormat = a.Format
 		}
 	}
+	gs.placeBotBids(room, a)
 
-	gs.logGeneral(room, fmt.Sprintf("Federation auction started: %s on %s", facility.name, planet))
+	gs.logGeneral(room, fmt.Sprintf("Federation %s auction started: %s on %s", auctionFormatLabel(a.Format), facilityType, planet))
 }
 
 // endFederationAuction determines winner and creates the facility
@@ -4111,32 +4926,36 @@ func (gs *GameServer) endFederationAuction(room *Room) {
 	}
 
 	auction := room.ActiveAuction
-
-	// Find highest bidder
-	var winner PlayerID
-	highestBid := 0
-
-	for playerID, bid := range auction.Bids {
-		if bid > highestBid {
-			winner = playerID
-			highestBid = bid
-		}
+	// A reclaimed facility that goes unsold waits before being offered again
+	if _, reclaimed := findFacility(room, auction.FacilityID); reclaimed != nil {
+		reclaimed.NextAuctionTurn = room.Turn + reclaimedAuctionRetry
 	}
 
+	// Rank bids deterministically, falling back past anyone who can no longer pay
+	releaseAllBids(room, auction)
+	winnerPlayer, highestBid, ranked := gs.pickAuctionWinner(room, auction)
+
 	// Create facility and charge winner
-	if winner != "" && highestBid > 0 {
-		winnerPlayer := room.Players[winner]
-		if winnerPlayer != nil && winnerPlayer.Money >= highestBid {
-			// Charge the winner
-			winnerPlayer.Money -= highestBid
-			winnerPlayer.FacilityInvestment += highestBid
-
-			// Create the facility
-			planet := room.Planets[auction.Planet]
-			if planet != nil {
-				if planet.Facilities == nil {
-					planet.Facilities = []*Facility{}
-				}
+	if winnerPlayer != nil {
+		winner := winnerPlayer.ID
+		// Charge the winner
+		winnerPlayer.Money -= highestBid
+		winnerPlayer.FacilityInvestment += highestBid
+		recordDeduction(winnerPlayer, deductFacilities, highestBid)
+
+		// Create the facility
+		planet := room.Planets[auction.Planet]
+		if planet != nil {
+			if planet.Facilities == nil {
+				planet.Facilities = []*Facility{}
+			}
+			if _, reclaimed := findFacility(room, auction.FacilityID); reclaimed != nil && reclaimed.Owner == "" {
+				// A reclaimed facility changes hands rather than a new one being built
+				reclaimed.Owner = winner
+				reclaimed.OwnerName = winnerPlayer.Name
+				reclaimed.PurchasePrice = highestBid
+				reclaimed.Shares = map[PlayerID]int{winner: facilityShareCount}
+			} else {
 				facID := fmt.Sprintf("facility_%s_%d_%d", strings.ReplaceAll(strings.ToLower(auction.Planet), " ", "_"), room.Turn, rand.Intn(1000))
 				newFacility := &Facility{
 					ID:            facID,
@@ -4146,58 +4965,56 @@ func (gs *GameServer) endFederationAuction(room *Room) {
 					UsageCharge:   auction.UsageCharge,
 					AccruedMoney:  0,
 					PurchasePrice: highestBid,
+					Shares:        map[PlayerID]int{winner: facilityShareCount},
 				}
 				planet.Facilities = append(planet.Facilities, newFacility)
-				// Determine second-highest bid (if any)
-				var secondID PlayerID
-				secondBid := 0
-				for playerID, bid := range auction.Bids {
-					if playerID == winner {
-						continue
-					}
-					if bid > secondBid {
-						secondBid = bid
-						secondID = playerID
-					}
+			}
+			// Determine the next-ranked bid (if any)
+			var secondID PlayerID
+			secondBid := 0
+			for _, b := range ranked {
+				if b.Player != winner {
+					secondID, secondBid = b.Player, b.Amount
+					break
 				}
-				secondName := "another bidder"
-				if secondID != "" {
-					if sp := room.Players[secondID]; sp != nil {
-						secondName = sp.Name
-					}
-				} else {
-					secondName = "no competing bids"
+			}
+			secondName := "another bidder"
+			if secondID != "" {
+				if sp := room.Players[secondID]; sp != nil {
+					secondName = sp.Name
 				}
+			} else {
+				secondName = "no competing bids"
+			}
 
-				// Announce winner to all players
-				for _, p := range room.Players {
-					if p.ID == winner {
-						msg := fmt.Sprintf("Congratulations! You won the %s on %s for %d credits. You'll collect %d credits per turn from other players who dock there.",
-							auction.FacilityType, auction.Planet, highestBid, auction.UsageCharge)
-						if secondBid > 0 {
-							msg += fmt.Sprintf(" The next highest bid was %d credits from %s.", secondBid, secondName)
-						} else {
-							msg += " There were no competing bids."
-						}
-						gs.enqueueModal(p, "Auction Won!", msg)
+			// Announce winner to all players
+			for _, p := range room.Players {
+				if p.ID == winner {
+					msg := fmt.Sprintf("Congratulations! You won the %s on %s for %d credits. You'll collect %d credits per turn from other players who dock there.",
+						auction.FacilityType, auction.Planet, highestBid, auction.UsageCharge)
+					if secondBid > 0 {
+						msg += fmt.Sprintf(" The next highest bid was %d credits from %s.", secondBid, secondName)
 					} else {
-						msg := fmt.Sprintf("%s won the %s on %s for %d credits.",
-							winnerPlayer.Name, auction.FacilityType, auction.Planet, highestBid)
-						if secondBid > 0 {
-							msg += fmt.Sprintf(" The next highest bid was %d credits from %s.", secondBid, secondName)
-						} else {
-							msg += " No other bids were placed."
-						}
-						gs.enqueueModal(p, "Auction Results", msg)
+						msg += " There were no competing bids."
 					}
+					gs.enqueueModal(p, "Auction Won!", msg)
+				} else {
+					msg := fmt.Sprintf("%s won the %s on %s for %d credits.",
+						winnerPlayer.Name, auction.FacilityType, auction.Planet, highestBid)
+					if secondBid > 0 {
+						msg += fmt.Sprintf(" The next highest bid was %d credits from %s.", secondBid, secondName)
+					} else {
+						msg += " No other bids were placed."
+					}
+					gs.enqueueModal(p, "Auction Results", msg)
 				}
+			}
 
-				detail := "no other bids"
-				if secondBid > 0 {
-					detail = fmt.Sprintf("next highest: %s at $%d", secondName, secondBid)
-				}
-				gs.logGeneral(room, fmt.Sprintf("%s won %s on %s for $%d (%s)", winnerPlayer.Name, auction.FacilityType, auction.Planet, highestBid, detail))
+			detail := "no other bids"
+			if secondBid > 0 {
+				detail = fmt.Sprintf("next highest: %s at $%d", secondName, secondBid)
 			}
+			gs.logGeneral(room, fmt.Sprintf("%s won %s on %s for $%d (%s)", winnerPlayer.Name, auction.FacilityType, auction.Planet, highestBid, detail))
 		}
 	} else {
 		// No valid bids
@@ -4225,40 +5042,34 @@ func (gs *GameServer) handleFacilities(room *Room) {
 				continue
 			}
 
-			// Charge all players at this location who don't own the facility
+			// Charge every docked ship at this location whose owner doesn't own the facility
 			for _, p := range room.Players {
-				if p.Bankrupt || p.InTransit {
+				if p.Bankrupt {
 					continue
 				}
 
-				if p.CurrentPlanet == planetName && p.ID != facility.Owner {
-					charge := facility.UsageCharge
-					p.Money -= charge
-					facility.AccruedMoney += charge
+				for _, ship := range dockedShips(p, planetName) {
+					if p.ID == facility.Owner || p.Bankrupt {
+						break
+					}
+					charge := guildFacilityCharge(p, facility.UsageCharge)
+					recordDeduction(p, deductFacilityFees, charge)
+					if facility.Owner != "" {
+						facility.AccruedMoney += charge
+					}
 
-					gs.logAction(room, p, fmt.Sprintf("Facility charge: $%d at %s (%s)", charge, planetName, facility.Type))
+					gs.logAction(room, p, fmt.Sprintf("Facility charge: $%d at %s (%s)%s", charge, planetName, facility.Type, shipSuffix(p, ship)))
 					if !p.IsBot {
 						if _, ok := chargesByPlayer[p.ID]; !ok {
 							chargesByPlayer[p.ID] = make(map[string]int)
 						}
 						chargesByPlayer[p.ID][planetName] += charge
 					}
-
-					if p.Money < -500 && !p.Bankrupt {
-						if !p.IsBot {
-							gs.enqueueModal(p, "Game Over", "Your ship was impounded for unpaid facility fees. You may continue watching.")
-						}
-						p.Bankrupt = true
-						room.News = append(room.News, NewsItem{
-							Headline:       p.Name + " bankrupted by facility fees at " + planetName,
-							Planet:         planetName,
-							TurnsRemaining: 3,
-						})
-					}
+					gs.chargeWithCredit(room, p, charge, "facility fees", planetName)
 				}
 
-				if p.CurrentPlanet == planetName && p.ID == facility.Owner && facility.AccruedMoney > 0 {
-					collected := facility.AccruedMoney
+				if p.ID == facility.Owner && facility.AccruedMoney > 0 && len(dockedShips(p, planetName)) > 0 {
+					collected := gs.distributeFacilityRevenue(room, planetName, facility, facility.AccruedMoney)
 					p.Money += collected
 					facility.AccruedMoney = 0
 
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+)
+
+const facilityShareCount = 100 // every facility is divided into this many shares
+
+// ShareAsk is a standing offer to sell facility shares at a fixed price per share.
+// Owners issue shares by listing their own; any holder can list theirs.
+type ShareAsk struct {
+	ID         string   `json:"id"`
+	Seller     PlayerID `json:"seller"`
+	SellerName string   `json:"sellerName"`
+	Shares     int      `json:"shares"`
+	Price      int      `json:"price"` // per share
+}
+
+// facilityShares returns the cap table, initialising legacy facilities to 100% owner.
+func facilityShares(f *Facility) map[PlayerID]int {
+	if f.Shares == nil {
+		f.Shares = map[PlayerID]int{f.Owner: facilityShareCount}
+	}
+	return f.Shares
+}
+
+// listedShares counts shares a holder already has on the order book.
+func listedShares(f *Facility, pid PlayerID) int {
+	total := 0
+	for _, a := range f.ShareAsks {
+		if a.Seller == pid {
+			total += a.Shares
+		}
+	}
+	return total
+}
+
+// findFacility locates a facility and its planet by id.
+func findFacility(room *Room, id string) (*Planet, *Facility) {
+	for _, planet := range room.Planets {
+		if planet == nil {
+			continue
+		}
+		for _, f := range planet.Facilities {
+			if f != nil && f.ID == id {
+				return planet, f
+			}
+		}
+	}
+	return nil, nil
+}
+
+// shareBookValue is the facility investment attributed to n shares.
+func shareBookValue(f *Facility, n int) int {
+	return f.PurchasePrice * n / facilityShareCount
+}
+
+// handleListShares puts shares on the facility order book at a fixed price.
+func (gs *GameServer) handleListShares(room *Room, p *Player, facilityID string, shares, price int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	_, f := findFacility(room, facilityID)
+	if f == nil || shares <= 0 || price <= 0 {
+		return
+	}
+	free := facilityShares(f)[p.ID] - listedShares(f, p.ID)
+	if shares > free {
+		gs.enqueueModal(p, "Not Enough Shares", fmt.Sprintf("You only have %d unlisted shares in this %s.", maxInt(0, free), f.Type))
+		return
+	}
+	f.ShareAsks = append(f.ShareAsks, &ShareAsk{ID: randID(), Seller: p.ID, SellerName: p.Name, Shares: shares, Price: price})
+	gs.logAction(room, p, fmt.Sprintf("Listed %d shares of %s at $%d each", shares, f.Type, price))
+}
+
+// handleCancelShares withdraws all of the player's asks on a facility.
+func (gs *GameServer) handleCancelShares(room *Room, p *Player, facilityID string) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	_, f := findFacility(room, facilityID)
+	if f == nil {
+		return
+	}
+	kept := f.ShareAsks[:0]
+	for _, a := range f.ShareAsks {
+		if a.Seller != p.ID {
+			kept = append(kept, a)
+		}
+	}
+	f.ShareAsks = kept
+}
+
+// handleBuyShares fills the cheapest asks first until the requested shares are bought.
+func (gs *GameServer) handleBuyShares(room *Room, p *Player, facilityID string, shares int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	planet, f := findFacility(room, facilityID)
+	if f == nil || shares <= 0 {
+		return
+	}
+	sort.SliceStable(f.ShareAsks, func(i, j int) bool { return f.ShareAsks[i].Price < f.ShareAsks[j].Price })
+	bought, spent := 0, 0
+	for _, ask := range f.ShareAsks {
+		if bought >= shares {
+			break
+		}
+		if ask.Seller == p.ID || ask.Shares <= 0 {
+			continue
+		}
+		n := minInt(shares-bought, ask.Shares)
+		n = minInt(n, spendableFunds(p)/ask.Price)
+		if n <= 0 {
+			break
+		}
+		cost := n * ask.Price
+		p.Money -= cost
+		recordDeduction(p, deductFacilities, cost)
+		gs.creditPlayer(room, ask.Seller, cost)
+		recordSaleFor(room, ask.Seller, cost, 0)
+		gs.transferShares(room, f, ask.Seller, p.ID, n)
+		ask.Shares -= n
+		bought += n
+		spent += cost
+		if seller := room.Players[ask.Seller]; seller != nil {
+			gs.logAction(room, seller, fmt.Sprintf("Sold %d shares of %s on %s to %s for $%d", n, f.Type, planet.Name, p.Name, cost))
+		}
+	}
+	kept := f.ShareAsks[:0]
+	for _, a := range f.ShareAsks {
+		if a.Shares > 0 {
+			kept = append(kept, a)
+		}
+	}
+	f.ShareAsks = kept
+	if bought == 0 {
+		gs.enqueueModal(p, "No Shares Bought", "There were no affordable shares for sale in that facility.")
+		return
+	}
+	gs.logAction(room, p, fmt.Sprintf("Bought %d shares of %s on %s for $%d", bought, f.Type, planet.Name, spent))
+}
+
+// transferShares moves shares between holders and keeps FacilityInvestment in step with book value.
+func (gs *GameServer) transferShares(room *Room, f *Facility, from, to PlayerID, n int) {
+	shares := facilityShares(f)
+	shares[from] -= n
+	if shares[from] <= 0 {
+		delete(shares, from)
+	}
+	shares[to] += n
+	value := shareBookValue(f, n)
+	adjustFacilityInvestment(room, from, -value)
+	adjustFacilityInvestment(room, to, value)
+}
+
+// adjustFacilityInvestment updates a player's facility book value whether they're
+// present in the room or only persisted.
+func adjustFacilityInvestment(room *Room, pid PlayerID, delta int) {
+	if pl := room.Players[pid]; pl != nil {
+		pl.FacilityInvestment = maxInt(0, pl.FacilityInvestment+delta)
+		return
+	}
+	if snap := room.Persist[pid]; snap != nil {
+		snap.FacilityInvestment = maxInt(0, snap.FacilityInvestment+delta)
+	}
+}
+
+// creditPlayer pays money to a player who may have left the room.
+func (gs *GameServer) creditPlayer(room *Room, pid PlayerID, amount int) {
+	if pl := room.Players[pid]; pl != nil {
+		pl.Money += amount
+		return
+	}
+	if snap := room.Persist[pid]; snap != nil {
+		snap.Money += amount
+	}
+}
+
+// distributeFacilityRevenue splits collected revenue pro rata across shareholders.
+// The owner receives any rounding remainder. Returns the owner's portion.
+func (gs *GameServer) distributeFacilityRevenue(room *Room, planetName string, f *Facility, amount int) int {
+	shares := facilityShares(f)
+	holders := make([]PlayerID, 0, len(shares))
+	for pid := range shares {
+		holders = append(holders, pid)
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i] < holders[j] })
+	paid := 0
+	for _, pid := range holders {
+		if pid == f.Owner {
+			continue
+		}
+		cut := amount * shares[pid] / facilityShareCount
+		if cut <= 0 {
+			continue
+		}
+		paid += cut
+		gs.creditPlayer(room, pid, cut)
+		if pl := room.Players[pid]; pl != nil {
+			gs.logAction(room, pl, fmt.Sprintf("Dividend: $%d from %s on %s (%d shares)", cut, f.Type, planetName, shares[pid]))
+		}
+	}
+	return amount - paid
+}
+
+// shareHoldingsPayload lists the player's stakes for the `you` payload.
+func shareHoldingsPayload(room *Room, p *Player) []map[string]interface{} {
+	out := []map[string]interface{}{}
+	for _, name := range planetNames(room.Planets) {
+		planet := room.Planets[name]
+		for _, f := range planet.Facilities {
+			if f == nil {
+				continue
+			}
+			n := facilityShares(f)[p.ID]
+			if n <= 0 {
+				continue
+			}
+			out = append(out, map[string]interface{}{
+				"facilityId": f.ID,
+				"type":       f.Type,
+				"planet":     name,
+				"shares":     n,
+				"listed":     listedShares(f, p.ID),
+				"bookValue":  shareBookValue(f, n),
+				"isOwner":    f.Owner == p.ID,
+			})
+		}
+	}
+	return out
+}
+
+func cloneShares(in map[PlayerID]int) map[PlayerID]int {
+	out := make(map[PlayerID]int, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func shareAsksPayload(f *Facility) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(f.ShareAsks))
+	for _, a := range f.ShareAsks {
+		out = append(out, map[string]interface{}{
+			"id":         a.ID,
+			"seller":     a.Seller,
+			"sellerName": a.SellerName,
+			"shares":     a.Shares,
+			"price":      a.Price,
+		})
+	}
+	return out
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tax deductions
+const (
+	deductFacilities   = "facilities"   // facility purchases and shares
+	deductUpgrades     = "upgrades"     // ship upgrades and armaments
+	deductFacilityFees = "facilityFees" // usage charges paid at other captains' facilities
+)
+
+const defaultTaxInterval = 10 // turns between assessments
+const minTaxInterval = 5
+const maxTaxInterval = 50
+const maxTaxBrackets = 6
+const maxTaxRatePct = 60
+const maxTaxStatements = 5 // statements kept per player
+
+// TaxBracket taxes the slice of taxable profit above From at RatePct, up to the next
+// bracket's From. Profit below the first bracket is tax-free.
+type TaxBracket struct {
+	From    int `json:"from"`
+	RatePct int `json:"ratePct"`
+}
+
+var defaultTaxBrackets = []TaxBracket{
+	{From: 2000, RatePct: 10},
+	{From: 10000, RatePct: 20},
+	{From: 30000, RatePct: 30},
+}
+
+// TaxLedger accumulates a player's realised trading profit and deductible spending
+// for the current tax period.
+type TaxLedger struct {
+	Sales       int            // sale proceeds
+	CostOfGoods int            // what the goods sold had cost, at average cost
+	Deductions  map[string]int // by deduction kind
+	Statements  []TaxStatement
+}
+
+// TaxStatement is an itemised assessment.
+type TaxStatement struct {
+	Turn        int            `json:"turn"`
+	Sales       int            `json:"sales"`
+	CostOfGoods int            `json:"costOfGoods"`
+	Profit      int            `json:"profit"`
+	Deductions  map[string]int `json:"deductions"`
+	Taxable     int            `json:"taxable"`
+	Bands       []TaxBand      `json:"bands"`
+	Tax         int            `json:"tax"`
+}
+
+// TaxBand is the tax due within one bracket.
+type TaxBand struct {
+	From    int `json:"from"`
+	To      int `json:"to"` // 0 for the top bracket
+	RatePct int `json:"ratePct"`
+	Amount  int `json:"amount"` // taxable profit falling in the band
+	Tax     int `json:"tax"`
+}
+
+func cloneTaxLedger(l *TaxLedger) *TaxLedger {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	c.Deductions = cloneIntMap(l.Deductions)
+	c.Statements = append([]TaxStatement(nil), l.Statements...)
+	return &c
+}
+
+func (p *Player) taxLedger() *TaxLedger {
+	if p.Tax == nil {
+		p.Tax = &TaxLedger{Deductions: map[string]int{}}
+	}
+	if p.Tax.Deductions == nil {
+		p.Tax.Deductions = map[string]int{}
+	}
+	return p.Tax
+}
+
+// recordSale books a sale's proceeds against what the goods cost.
+func recordSale(p *Player, proceeds, cost int) {
+	l := p.taxLedger()
+	l.Sales += proceeds
+	l.CostOfGoods += cost
+}
+
+// recordSaleFor books a sale for a player who may have left the room, like creditPlayer.
+// Facilities, shares and upgrades were deducted when bought, so their resale is booked
+// with no cost.
+func recordSaleFor(room *Room, pid PlayerID, proceeds, cost int) {
+	if pl := room.Players[pid]; pl != nil {
+		recordSale(pl, proceeds, cost)
+		return
+	}
+	if snap := room.Persist[pid]; snap != nil {
+		if snap.Tax == nil {
+			snap.Tax = &TaxLedger{Deductions: map[string]int{}}
+		}
+		snap.Tax.Sales += proceeds
+		snap.Tax.CostOfGoods += cost
+	}
+}
+
+// recordDeduction books deductible spending of the given kind.
+func recordDeduction(p *Player, kind string, amount int) {
+	if amount > 0 {
+		p.taxLedger().Deductions[kind] += amount
+	}
+}
+
+func (room *Room) taxInterval() int {
+	if room.TaxInterval <= 0 {
+		return defaultTaxInterval
+	}
+	return room.TaxInterval
+}
+
+func (room *Room) taxBrackets() []TaxBracket {
+	if room.TaxBrackets == nil {
+		return defaultTaxBrackets
+	}
+	return room.TaxBrackets
+}
+
+// taxStatement works out the tax on the ledger under the room's brackets.
+func taxStatement(room *Room, l *TaxLedger) TaxStatement {
+	st := TaxStatement{Turn: room.Turn, Deductions: map[string]int{}}
+	if l != nil {
+		st.Sales = l.Sales
+		st.CostOfGoods = l.CostOfGoods
+		st.Deductions = cloneIntMap(l.Deductions)
+	}
+	st.Profit = st.Sales - st.CostOfGoods
+	deducted := 0
+	for _, v := range st.Deductions {
+		deducted += v
+	}
+	st.Taxable = maxInt(0, st.Profit-deducted)
+	brackets := room.taxBrackets()
+	for i, b := range brackets {
+		if st.Taxable <= b.From {
+			break
+		}
+		band := TaxBand{From: b.From, RatePct: b.RatePct}
+		top := st.Taxable
+		if i+1 < len(brackets) {
+			band.To = brackets[i+1].From
+			top = minInt(top, band.To)
+		}
+		band.Amount = top - b.From
+		band.Tax = band.Amount * b.RatePct / 100
+		st.Bands = append(st.Bands, band)
+		st.Tax += band.Tax
+	}
+	return st
+}
+
+// assessIncomeTax runs every tax interval: each captain is taxed on the period's
+// realised profit less deductions, sent an itemised statement, and starts a fresh ledger.
+func (gs *GameServer) assessIncomeTax(room *Room) {
+	if room.Turn <= 0 || room.Turn%room.taxInterval() != 0 {
+		return
+	}
+	for _, p := range room.Players {
+		if p.Bankrupt {
+			continue
+		}
+		st := taxStatement(room, p.Tax)
+		l := p.taxLedger()
+		l.Statements = append(l.Statements, st)
+		if len(l.Statements) > maxTaxStatements {
+			l.Statements = l.Statements[len(l.Statements)-maxTaxStatements:]
+		}
+		l.Sales, l.CostOfGoods, l.Deductions = 0, 0, map[string]int{}
+		gs.logAction(room, p, fmt.Sprintf("Income tax assessed: $%d on taxable profit of $%d", st.Tax, st.Taxable))
+		if !p.IsBot {
+			gs.enqueueModal(p, "Tax Statement", taxStatementText(room, st))
+		}
+		if st.Tax > 0 {
+			gs.chargeWithCredit(room, p, st.Tax, "taxes", p.CurrentPlanet)
+		}
+	}
+}
+
+func taxStatementText(room *Room, st TaxStatement) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Federation income tax for the %d turns to turn %d.\n\n", room.taxInterval(), st.Turn)
+	fmt.Fprintf(&b, "Sales: %d\nCost of goods sold: -%d\nRealised profit: %d\n", st.Sales, st.CostOfGoods, st.Profit)
+	kinds := make([]string, 0, len(st.Deductions))
+	for k := range st.Deductions {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	labels := map[string]string{deductFacilities: "Facility investment", deductUpgrades: "Ship upgrades", deductFacilityFees: "Facility fees"}
+	for _, k := range kinds {
+		fmt.Fprintf(&b, "%s: -%d\n", defaultStr(labels[k], k), st.Deductions[k])
+	}
+	fmt.Fprintf(&b, "Taxable profit: %d\n\n", st.Taxable)
+	for _, band := range st.Bands {
+		if band.To > 0 {
+			fmt.Fprintf(&b, "%d%% on %d (%d to %d): %d\n", band.RatePct, band.Amount, band.From, band.To, band.Tax)
+		} else {
+			fmt.Fprintf(&b, "%d%% on %d (over %d): %d\n", band.RatePct, band.Amount, band.From, band.Tax)
+		}
+	}
+	fmt.Fprintf(&b, "Tax due: %d credits.", st.Tax)
+	return b.String()
+}
+
+// handleSetTaxBrackets replaces the room's brackets and assessment interval. Tax
+// rules bind every captain, so they're a room rule for the private room's owner.
+func (gs *GameServer) handleSetTaxBrackets(room *Room, p *Player, brackets []TaxBracket, interval int) {
+	room.mu.Lock()
+	defer func() { room.mu.Unlock(); gs.sendRoomState(room, nil) }()
+	if !isRoomOwner(room, p) {
+		gs.enqueueModal(p, "Room Rules Locked", "Only the creator of a private room can change the tax brackets.")
+		return
+	}
+	if len(brackets) > maxTaxBrackets {
+		gs.enqueueModal(p, "Invalid Tax Brackets", fmt.Sprintf("At most %d brackets are allowed.", maxTaxBrackets))
+		return
+	}
+	for i, b := range brackets {
+		if b.From < 0 || b.RatePct < 0 || b.RatePct > maxTaxRatePct || (i > 0 && b.From <= brackets[i-1].From) {
+			gs.enqueueModal(p, "Invalid Tax Brackets", fmt.Sprintf("Brackets need increasing thresholds and rates between 0 and %d%%.", maxTaxRatePct))
+			return
+		}
+	}
+	room.TaxBrackets = append([]TaxBracket{}, brackets...)
+	if interval > 0 {
+		room.TaxInterval = clampInt(interval, minTaxInterval, maxTaxInterval)
+	}
+	gs.logGeneral(room, fmt.Sprintf("%s set new Federation tax brackets, assessed every %d turns", p.Name, room.taxInterval()))
+}
+
+// taxPayload describes the room's tax rules and the player's running estimate.
+func taxPayload(room *Room, p *Player) map[string]interface{} {
+	interval := room.taxInterval()
+	out := map[string]interface{}{
+		"brackets":       room.taxBrackets(),
+		"interval":       interval,
+		"nextAssessment": (room.Turn/interval + 1) * interval,
+		"estimate":       taxStatement(room, p.Tax),
+	}
+	if p.Tax != nil && len(p.Tax.Statements) > 0 {
+		out["statements"] = append([]TaxStatement(nil), p.Tax.Statements...)
+	}
+	return out
+}